@@ -0,0 +1,717 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"hypermark/frontend"
+	"hypermark/hackerNews"
+	"hypermark/urlMode"
+	"hypermark/utils"
+	"os"
+	"strings"
+	"time"
+
+	cli "github.com/jawher/mow.cli"
+)
+
+// Exit codes of the commands. mow.cli exits with 2 when a command is
+// used wrong, as does lint when a file cannot be read.
+const (
+	exitOK      = 0
+	exitFailure = 1
+)
+
+// Whether the options that have a default in the config file were given.
+var setByUser = map[string]*bool{
+	"o":          new(bool),
+	"c":          new(bool),
+	"bak":        new(bool),
+	"duplicates": new(bool),
+}
+
+// The commands of the app, in the order they are listed in its help.
+var commands = []struct {
+	name, desc string
+	init       cli.CmdInitializer
+}{
+	{"add", "Save a bytemark for a URL", cmdAdd},
+	{"list ls", "List the bytemarks of a hyperpath", cmdList},
+	{"rm", "Remove bytemarks from a hyperpath", cmdRemove},
+	{"mv", "Move bytemarks to another hyperpath", cmdMove},
+	{"send", "Copy bytemarks to another hyperpath", cmdSend},
+	{"mark", "Set the read state of bytemarks", cmdMark},
+	{"read", "Mark bytemarks read and move them to the archive", cmdRead},
+	{"open", "Open bytemarks in the browser", cmdOpen},
+	{"hn", "Save articles from the Hacker News front page", cmdHN},
+	{"hyperpaths hp", "Manage hyperpaths", cmdHyperpaths},
+	{"tags", "Count the tags of the bytemarks", cmdTags},
+	{"dedupe", "Find and merge duplicate bytemarks in every hyperpath", cmdDedupe},
+	{"undo", "Revert the last saves to a hyperpath", cmdUndo},
+	{"tui", "Start the TUI", cmdTUI},
+	{"lint", "Check files for malformed bytemark tables", cmdLint},
+}
+
+// Whether name is a command or an alias of one, eg: ls.
+func isCommand(name string) bool {
+	for _, command := range commands {
+		for _, alias := range strings.Fields(command.name) {
+			if alias == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func newApp() *cli.Cli {
+	app := cli.App("hypermark", "Save links as bytemarks to markdown files.")
+	app.LongDesc = "Without a command, pick Hacker News articles to save " +
+		"(same as 'hypermark hn').\nThe command-less form of older versions, " +
+		"eg: hypermark -k rust notes.md, still works but is deprecated."
+
+	app.StringPtr(&configFile, cli.StringOpt{
+		Name: "config",
+		Desc: "Path to the config file (default $XDG_CONFIG_HOME/hypermark/config.toml)",
+	})
+	app.BoolPtr(&backup, cli.BoolOpt{
+		Name:      "bak",
+		Desc:      "Keep a .bak of files before they are rewritten",
+		SetByUser: setByUser["bak"],
+	})
+
+	app.Before = configure
+	app.Action = func() {
+		articles := hackerNews.ScrapeHN()
+		outputPath := openOutput(nil)
+		defer outputPath.Close()
+		exitOnError(saveSelectedArticles(articles, outputPath, clipboardOut))
+	}
+
+	for _, command := range commands {
+		app.Command(command.name, command.desc, command.init)
+	}
+	return app
+}
+
+// Options whose values may start with -, eg: --tag -read. mow.cli would
+// take those for options, so they are joined: --tag=-read
+var dashValueOptions = map[string]bool{"--tag": true}
+
+func joinDashValues(args []string) []string {
+	joined := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		if dashValueOptions[args[i]] && i+1 < len(args) &&
+			strings.HasPrefix(args[i+1], "-") {
+			joined = append(joined, args[i]+"="+args[i+1])
+			i++
+			continue
+		}
+		joined = append(joined, args[i])
+	}
+	return joined
+}
+
+// Apply the config file once the options are known.
+func configure() {
+	given := make(map[string]bool)
+	for name, set := range setByUser {
+		given[name] = *set
+	}
+	exitOnError(applyConfig(given))
+}
+
+func exitOnError(err error) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "hypermark: %v\n", err)
+		cli.Exit(exitFailure)
+	}
+}
+
+// The options of commands that write bytemarks somewhere.
+func outputOptions(cmd *cli.Cmd) (file *string) {
+	cmd.BoolPtr(&o, cli.BoolOpt{
+		Name:      "o overwrite",
+		Desc:      "Overwrite the target file instead of appending to the end",
+		SetByUser: setByUser["o"],
+	})
+	cmd.BoolPtr(&clipboardOut, cli.BoolOpt{
+		Name:      "c clipboard",
+		Desc:      "Write to the system clipboard",
+		SetByUser: setByUser["c"],
+	})
+	cmd.BoolPtr(&stdout, cli.BoolOpt{
+		Name: "stdout",
+		Desc: "Write to stdout",
+	})
+	cmd.StringPtr(&to, cli.StringOpt{
+		Name: "t to",
+		Desc: "Name of the hyperpath to write to instead of the first one",
+	})
+	file = cmd.String(cli.StringOpt{
+		Name: "f file",
+		Desc: "Write to this file instead of a hyperpath",
+	})
+	cmd.StringPtr(&duplicates, cli.StringOpt{
+		Name:      "duplicates",
+		Desc:      "What to do with bytemarks that are already saved: skip, warn or merge",
+		SetByUser: setByUser["duplicates"],
+	})
+	// The config is applied after the options of the command are read.
+	cmd.Before = configure
+	return file
+}
+
+func openOutput(file *string) *os.File {
+	tail := make([]string, 0)
+	if file != nil && *file != "" {
+		tail = append(tail, *file)
+	}
+	outputPath, err := utils.ChooseOutputPath(tail, to, o, stdout, clipboardOut)
+	if err != nil {
+		if err.Error() == utils.EARLY_EXIT {
+			cli.Exit(exitOK)
+		}
+		exitOnError(err)
+	}
+	return outputPath
+}
+
+func cmdAdd(cmd *cli.Cmd) {
+	cmd.Spec = "[OPTIONS] [URL...]"
+	cmd.LongDesc = "Use - to read URLs from stdin, one per line. Without a " +
+		"URL, it is read from the clipboard, or stdin if the source setting " +
+		"is stdin.\nExits with 1 if any of the URLs failed; the others are " +
+		"still saved."
+	file := outputOptions(cmd)
+	jobs := cmd.Int(cli.IntOpt{
+		Name:  "j jobs",
+		Value: urlMode.DEFAULT_WORKERS,
+		Desc:  "Number of pages to fetch at once",
+	})
+	timeout := cmd.String(cli.StringOpt{
+		Name:  "timeout",
+		Value: "0",
+		Desc:  "Give up on the pages not fetched after this long, eg: 2m (0 for never)",
+	})
+	args := cmd.StringsArg("URL", nil, "URLs to save, or - for stdin")
+
+	cmd.Action = func() {
+		if len(*args) == 0 {
+			bytemark, err := urlMode.BytemarkFromURL()
+			exitOnError(err)
+
+			outputPath := openOutput(file)
+			defer outputPath.Close()
+			exitOnError(saveBytemark(bytemark, outputPath, clipboardOut))
+			return
+		}
+
+		urls := make([]string, 0)
+		for _, arg := range *args {
+			if arg != "-" {
+				urls = append(urls, arg)
+				continue
+			}
+			fromStdin, err := urlMode.ReadURLs(os.Stdin)
+			exitOnError(err)
+			urls = append(urls, fromStdin...)
+		}
+
+		deadline, err := time.ParseDuration(*timeout)
+		if err != nil {
+			exitOnError(fmt.Errorf("Invalid timeout: %s", *timeout))
+		}
+		fetcher := urlMode.NewFetcher()
+		fetcher.Workers = *jobs
+		fetcher.Deadline = deadline
+
+		bytemarks := make([]utils.Bytemark, 0)
+		for _, result := range fetcher.Fetch(urls) {
+			if result.Err != nil {
+				fmt.Fprintf(os.Stderr, "failed %s: %v\n", result.URL, result.Err)
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "ok     %s %s\n", result.URL, result.Bytemark.Title)
+			bytemarks = append(bytemarks, result.Bytemark)
+		}
+
+		code := exitOK
+		if len(bytemarks) != len(urls) {
+			code = exitFailure
+		}
+		if len(bytemarks) > 0 {
+			outputPath := openOutput(file)
+			writtenTo, written, err := writeBytemarks(
+				outputPath, bytemarks, clipboardOut)
+			outputPath.Close()
+			exitOnError(err)
+			fmt.Printf("%d of %d bytemarks were written to %s.\n",
+				written, len(urls), writtenTo)
+		}
+		cli.Exit(code)
+	}
+}
+
+// The --from option of commands that read a hyperpath.
+func fromOption(cmd *cli.Cmd) *string {
+	return cmd.String(cli.StringOpt{
+		Name: "F from",
+		Desc: "Name of the hyperpath to use instead of the first one",
+	})
+}
+
+// The hyperpath named by selector, or the first one.
+func resolveHyperpath(selector string) utils.Hyperpath {
+	if selector == "" {
+		hyperpaths, err := utils.GetHyperpaths()
+		exitOnError(err)
+		if len(hyperpaths) == 0 {
+			exitOnError(fmt.Errorf("No hyperpaths found."))
+		}
+		return hyperpaths[0]
+	}
+	hp, _, err := utils.ResolveHyperpath(selector)
+	exitOnError(err)
+	return hp
+}
+
+func loadCollection(selector string) *utils.Collection {
+	hp := resolveHyperpath(selector)
+	collection, err := utils.LoadCollection(hp)
+	exitOnError(err)
+	if n := len(collection.Quarantined()); n > 0 {
+		fmt.Fprintf(os.Stderr,
+			"hypermark: %d malformed tables in %s were skipped, see 'hypermark lint'.\n",
+			n, hp.Name)
+	}
+	return collection
+}
+
+func cmdList(cmd *cli.Cmd) {
+	cmd.Spec = "[-F=<name>] [--tag=<tag>...]"
+	cmd.LongDesc = "Positions are those of the whole hyperpath, so that they " +
+		"can be given to rm, mv and send when filtering."
+	from := fromOption(cmd)
+	tags := cmd.Strings(cli.StringsOpt{
+		Name: "tag",
+		Desc: "Only list bytemarks with the tag, or without it if it starts with - or !",
+	})
+
+	cmd.Action = func() {
+		collection := loadCollection(*from)
+		filter := utils.ParseTagFilter(*tags)
+		for i, bytemark := range collection.Bytemarks() {
+			if !filter.Match(bytemark) {
+				continue
+			}
+			fmt.Printf("%d. %s\n%s\n", i+1, bytemark.Title, bytemark.RootURL)
+			if len(bytemark.Tags) != 0 {
+				fmt.Printf("tags: %s\n", strings.Join(bytemark.Tags, ", "))
+			}
+			if bytemark.ReadState != "" {
+				fmt.Printf("state: %s\n", bytemark.ReadState)
+			}
+			fmt.Println()
+		}
+	}
+}
+
+func cmdTags(cmd *cli.Cmd) {
+	cmd.Spec = "[-F=<name>]"
+	from := cmd.String(cli.StringOpt{
+		Name: "F from",
+		Desc: "Name of the hyperpath to count the tags of (default: all of them)",
+	})
+
+	cmd.Action = func() {
+		var bytemarks []utils.Bytemark
+		if *from != "" {
+			bytemarks = loadCollection(*from).Bytemarks()
+		} else {
+			collections, err := utils.LoadAllCollections()
+			exitOnError(err)
+			bytemarks = utils.AllBytemarks(collections)
+		}
+		for _, count := range utils.CountTags(bytemarks) {
+			fmt.Printf("%d %s\n", count.Count, count.Tag)
+		}
+	}
+}
+
+const selectorDesc = "Position shown by the list command, range of " +
+	"positions (eg: 2-5) or URL of the bytemarks"
+
+func cmdRemove(cmd *cli.Cmd) {
+	cmd.Spec = "[-F=<name>] SELECTOR..."
+	from := fromOption(cmd)
+	selectors := cmd.StringsArg("SELECTOR", nil, selectorDesc)
+
+	cmd.Action = func() {
+		collection := loadCollection(*from)
+		indices, err := utils.SelectBytemarks(collection.Bytemarks(), *selectors)
+		exitOnError(err)
+		collection.RemoveBytemarks(indices)
+		exitOnError(collection.Save())
+		fmt.Printf("%d bytemarks removed from %s.\n",
+			len(indices), collection.Hyperpath.Name)
+	}
+}
+
+func cmdMove(cmd *cli.Cmd) {
+	sendCommand(cmd, true)
+}
+
+func cmdSend(cmd *cli.Cmd) {
+	sendCommand(cmd, false)
+}
+
+func sendCommand(cmd *cli.Cmd, move bool) {
+	cmd.Spec = "[-F=<name>] -t=<name> SELECTOR..."
+	from := fromOption(cmd)
+	target := cmd.String(cli.StringOpt{
+		Name: "t to",
+		Desc: "Name of the hyperpath to send to",
+	})
+	selectors := cmd.StringsArg("SELECTOR", nil, selectorDesc)
+
+	cmd.Action = func() {
+		src := loadCollection(*from)
+		dst := loadCollection(*target)
+		if src.Hyperpath.Path == dst.Hyperpath.Path {
+			exitOnError(fmt.Errorf(
+				"Cannot send to the same hyperpath: %s", dst.Hyperpath.Name))
+		}
+		indices, err := utils.SelectBytemarks(src.Bytemarks(), *selectors)
+		exitOnError(err)
+		duplicates, err := utils.SendBytemarks(src, dst, indices, move)
+		reportDuplicates(duplicates)
+		exitOnError(err)
+
+		verb := "sent"
+		if move {
+			verb = "moved"
+		}
+		fmt.Printf("%d bytemarks %s to %s.\n", len(indices), verb, dst.Hyperpath.Name)
+	}
+}
+
+func cmdMark(cmd *cli.Cmd) {
+	cmd.Spec = "[-F=<name>] STATE SELECTOR..."
+	from := fromOption(cmd)
+	state := cmd.StringArg("STATE", "", "unread, in-progress or read")
+	selectors := cmd.StringsArg("SELECTOR", nil, selectorDesc)
+
+	cmd.Action = func() {
+		readState, err := utils.ParseReadState(*state)
+		exitOnError(err)
+		collection := loadCollection(*from)
+		bytemarks := collection.Bytemarks()
+		indices, err := utils.SelectBytemarks(bytemarks, *selectors)
+		exitOnError(err)
+		for _, i := range indices {
+			bytemarks[i].SetReadState(readState)
+		}
+		collection.SetBytemarks(bytemarks)
+		exitOnError(collection.Save())
+		fmt.Printf("%d bytemarks marked %s.\n", len(indices), readState)
+	}
+}
+
+func cmdRead(cmd *cli.Cmd) {
+	cmd.Spec = "[-F=<name>] [--keep] SELECTOR..."
+	cmd.LongDesc = "The archive is the hyperpath named by archive in the " +
+		"[defaults] of the config."
+	from := fromOption(cmd)
+	keep := cmd.Bool(cli.BoolOpt{
+		Name: "keep",
+		Desc: "Only mark the bytemarks read, do not archive them",
+	})
+	selectors := cmd.StringsArg("SELECTOR", nil, selectorDesc)
+
+	cmd.Action = func() {
+		src := loadCollection(*from)
+		bytemarks := src.Bytemarks()
+		indices, err := utils.SelectBytemarks(bytemarks, *selectors)
+		exitOnError(err)
+
+		var dst *utils.Collection
+		if !*keep {
+			archive, err := utils.ArchiveHyperpath()
+			exitOnError(err)
+			if archive.Path != src.Hyperpath.Path {
+				dst = loadCollection(archive.Name)
+			}
+		}
+
+		selected := make(map[int]bool)
+		for _, i := range indices {
+			bytemarks[i].SetReadState(utils.Read)
+			selected[i] = true
+		}
+		if dst == nil {
+			src.SetBytemarks(bytemarks)
+			exitOnError(src.Save())
+			fmt.Printf("%d bytemarks marked read.\n", len(indices))
+			return
+		}
+
+		read := make([]utils.Bytemark, 0, len(indices))
+		kept := make([]utils.Bytemark, 0, len(bytemarks))
+		for i, bytemark := range bytemarks {
+			if selected[i] {
+				read = append(read, bytemark)
+			} else {
+				kept = append(kept, bytemark)
+			}
+		}
+		duplicates, err := utils.MoveBytemarks(src, kept, dst, read)
+		reportDuplicates(duplicates)
+		exitOnError(err)
+		fmt.Printf("%d bytemarks marked read and moved to %s.\n",
+			len(indices), dst.Hyperpath.Name)
+	}
+}
+
+func cmdOpen(cmd *cli.Cmd) {
+	cmd.Spec = "[-F=<name>] [-c] [--mark] SELECTOR..."
+	cmd.LongDesc = "URLs are opened with $BROWSER if it is set, in the " +
+		"terminal, otherwise with xdg-open or the opener of the OS."
+	from := fromOption(cmd)
+	comments := cmd.Bool(cli.BoolOpt{
+		Name: "c comments",
+		Desc: "Open the comments link instead of the URL",
+	})
+	mark := cmd.Bool(cli.BoolOpt{
+		Name: "mark",
+		Desc: "Mark unread bytemarks in-progress, as mark_opened in the config does",
+	})
+	selectors := cmd.StringsArg("SELECTOR", nil, selectorDesc)
+
+	cmd.Action = func() {
+		if *mark {
+			utils.MarkOpened = true
+		}
+		collection := loadCollection(*from)
+		bytemarks := collection.Bytemarks()
+		indices, err := utils.SelectBytemarks(bytemarks, *selectors)
+		exitOnError(err)
+
+		code := exitOK
+		marked := 0
+		for _, i := range indices {
+			ok, err := bytemarks[i].Open(*comments)
+			if err == utils.ErrNoComments {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", bytemarks[i].Title, err)
+				code = exitFailure
+				continue
+			}
+			exitOnError(err)
+			if ok {
+				marked++
+			}
+		}
+		if marked != 0 {
+			collection.SetBytemarks(bytemarks)
+			exitOnError(collection.Save())
+			fmt.Printf("%d bytemarks marked %s.\n", marked, utils.InProgress)
+		}
+		cli.Exit(code)
+	}
+}
+
+func cmdHN(cmd *cli.Cmd) {
+	cmd.Spec = "[-k=<keyword> | -s] [OPTIONS]"
+	cmd.LongDesc = "Without -k or -s, pick the articles to save."
+	keyword := cmd.String(cli.StringOpt{
+		Name: "k keyword",
+		Desc: "Save the articles with keyword in the title",
+	})
+	show := cmd.Bool(cli.BoolOpt{
+		Name: "s show",
+		Desc: "Show all articles and exit",
+	})
+	file := outputOptions(cmd)
+
+	cmd.Action = func() {
+		articles := hackerNews.ScrapeHN()
+		if *show {
+			showArticles(articles)
+			return
+		}
+
+		outputPath := openOutput(file)
+		defer outputPath.Close()
+		if *keyword != "" {
+			exitOnError(saveArticlesWithKeyword(articles, *keyword, outputPath, clipboardOut))
+		} else {
+			exitOnError(saveSelectedArticles(articles, outputPath, clipboardOut))
+		}
+	}
+}
+
+func cmdHyperpaths(cmd *cli.Cmd) {
+	listHyperpaths := func() {
+		hyperpaths, err := utils.GetHyperpaths()
+		exitOnError(err)
+		for i, hp := range hyperpaths {
+			fmt.Printf("%d %s: %s\n", i, hp.Name, hp.Path)
+		}
+	}
+	cmd.Action = listHyperpaths
+
+	cmd.Command("ls", "List the hyperpaths", func(cmd *cli.Cmd) {
+		cmd.Action = listHyperpaths
+	})
+	cmd.Command("add", "Add a file, directory or glob", func(cmd *cli.Cmd) {
+		cmd.Spec = "[-n=<name>] PATH"
+		name := cmd.String(cli.StringOpt{
+			Name: "n name",
+			Desc: "Name of the hyperpath (default: made from the path)",
+		})
+		path := cmd.StringArg("PATH", "", "File, directory or glob")
+		cmd.Action = func() {
+			hp, err := utils.AddHyperpath(*name, *path)
+			exitOnError(err)
+			fmt.Printf("Added %s: %s\n", hp.Name, hp.Path)
+		}
+	})
+	cmd.Command("rm", "Remove a hyperpath; its files are kept", func(cmd *cli.Cmd) {
+		cmd.Spec = "NAME"
+		name := cmd.StringArg("NAME", "", "Name of the hyperpath")
+		cmd.Action = func() {
+			hp, err := utils.RemoveHyperpath(*name)
+			exitOnError(err)
+			fmt.Printf("Removed %s: %s\n", hp.Name, hp.Path)
+		}
+	})
+}
+
+func cmdDedupe(cmd *cli.Cmd) {
+	cmd.Spec = "[-y | -n]"
+	cmd.LongDesc = "Bytemarks are duplicates if they have the same canonical " +
+		"URL or nearly the same title. Each group is merged into its first " +
+		"bytemark, with the tags and notes of the others."
+	yes := cmd.Bool(cli.BoolOpt{
+		Name: "y yes",
+		Desc: "Merge every group without asking",
+	})
+	dryRun := cmd.Bool(cli.BoolOpt{
+		Name: "n dry-run",
+		Desc: "Only list the duplicates",
+	})
+
+	cmd.Action = func() {
+		collections, err := utils.LoadAllCollections()
+		exitOnError(err)
+		groups := utils.FindDuplicates(collections)
+		if len(groups) == 0 {
+			fmt.Println("No duplicates found.")
+			return
+		}
+
+		reader := bufio.NewReader(os.Stdin)
+		merge := make([]utils.DuplicateGroup, 0)
+	ask:
+		for i, group := range groups {
+			fmt.Printf("%d.\n", i+1)
+			for _, location := range group {
+				fmt.Printf("  %s\n", location)
+			}
+			if *dryRun {
+				continue
+			}
+			if *yes {
+				merge = append(merge, group)
+				continue
+			}
+
+			fmt.Printf("Merge? y/N/q: ")
+			answer, err := reader.ReadString('\n')
+			if err != nil && answer == "" {
+				break
+			}
+			fmt.Println()
+			switch strings.ToLower(strings.TrimSpace(answer)) {
+			case "y", "yes":
+				merge = append(merge, group)
+			case "q":
+				break ask
+			}
+		}
+		if *dryRun {
+			fmt.Printf("%d groups of duplicates.\n", len(groups))
+			return
+		}
+
+		exitOnError(utils.MergeDuplicates(merge))
+		fmt.Printf("%d of %d groups merged.\n", len(merge), len(groups))
+	}
+}
+
+func cmdUndo(cmd *cli.Cmd) {
+	cmd.Spec = "[-F=<name>] [-n=<saves> | -l]"
+	cmd.LongDesc = "Saves are undone newest first, and only while the files " +
+		"are as those saves left them."
+	from := cmd.String(cli.StringOpt{
+		Name: "F from hyperpath",
+		Desc: "Name of the hyperpath to use instead of the first one",
+	})
+	steps := cmd.Int(cli.IntOpt{
+		Name:  "n",
+		Value: 1,
+		Desc:  "Number of saves to undo",
+	})
+	list := cmd.Bool(cli.BoolOpt{
+		Name: "l list",
+		Desc: "List the saves that can be undone, newest first",
+	})
+
+	cmd.Action = func() {
+		hp := resolveHyperpath(*from)
+		if *list {
+			entries, err := utils.JournalEntries(hp)
+			exitOnError(err)
+			for i, entry := range entries {
+				fmt.Printf("%d. %s\n", i+1, entry.Time.Local().Format("2006-01-02 15:04:05"))
+				for _, file := range entry.Files {
+					fmt.Printf("   %s\n", file.Path)
+				}
+			}
+			return
+		}
+
+		undone, err := utils.Undo(hp, *steps)
+		for _, entry := range undone {
+			fmt.Printf("Undid the save of %s.\n",
+				entry.Time.Local().Format("2006-01-02 15:04:05"))
+		}
+		exitOnError(err)
+	}
+}
+
+func cmdTUI(cmd *cli.Cmd) {
+	file := outputOptions(cmd)
+
+	cmd.Action = func() {
+		outputPath := openOutput(file)
+		defer outputPath.Close()
+
+		tail := make([]string, 0)
+		if *file != "" {
+			tail = append(tail, *file)
+		}
+		frontend.SetOutputVars(outputPath, tail, to, o, stdout, clipboardOut)
+		frontend.Start()
+	}
+}
+
+func cmdLint(cmd *cli.Cmd) {
+	cmd.Spec = "FILE..."
+	cmd.LongDesc = "Exits with 1 if problems were found and 2 if a file " +
+		"could not be read."
+	files := cmd.StringsArg("FILE", nil, "Markdown files to check")
+
+	cmd.Action = func() {
+		cli.Exit(lint(*files))
+	}
+}
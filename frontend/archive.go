@@ -0,0 +1,69 @@
+package frontend
+
+import (
+	"fmt"
+	"hypermark/frontend/styles"
+	"hypermark/utils"
+)
+
+// eg: "[x] ", before the title of a bytemark.
+func readStateMarker(bytemark utils.Bytemark) string {
+	switch bytemark.ReadState {
+	case utils.InProgress:
+		return styles.HRender(styles.OrangeRed, "[~]") + " "
+	case utils.Read:
+		return styles.HRender(styles.AquaMenthe, "[x]") + " "
+	}
+	return "[ ] "
+}
+
+func (m *model) showMessage(message string) {
+	m.setPrompt(message, []string{"Okay"})
+	m.promptMenu.cursorIndex = 0
+	m.currentView = sentConfirmationView
+}
+
+// Mark the bytemarks at targets read and move them to the archive
+// hyperpath. Other changes to the hyperpath being managed stay unsaved.
+func (m *model) archiveBytemarks(targets []int) {
+	state := &m.bytemarksManager
+
+	archive, err := utils.ArchiveHyperpath()
+	if err != nil {
+		m.showMessage(err.Error())
+		return
+	}
+	what := state.targetsString(targets)
+	if archive.Name == state.hyperpath.Name {
+		state.record()
+		for _, i := range targets {
+			state.bytemarks[i].SetReadState(utils.Read)
+		}
+		state.clearSelection()
+		return
+	}
+
+	read := make([]utils.Bytemark, 0, len(targets))
+	for _, i := range targets {
+		bytemark := state.bytemarks[i]
+		bytemark.SetReadState(utils.Read)
+		read = append(read, bytemark)
+	}
+	collection, err := utils.LoadCollection(archive)
+	if err != nil {
+		m.showMessage(err.Error())
+		return
+	}
+	duplicates, err := m.moveTargets(targets, read, collection)
+	if err != nil {
+		// The move is undone, so both hyperpaths are as they were.
+		m.showMessage("Nothing was archived: " + err.Error())
+		return
+	}
+
+	message := fmt.Sprintf("Archived %s to %s", what, archive.Name)
+	for _, duplicate := range duplicates {
+		message += "\n" + duplicate.String()
+	}
+	m.showMessage(message)
+}
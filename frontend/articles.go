@@ -13,43 +13,37 @@ import (
 
 func updateArticleMenu(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 	state := &m.articleMenu
-	var from int
-	var to int
-	if state.pageIndex == 0 {
-		from = 0
-		to = 15
-	} else {
-		from = 15
-		to = 30
-	}
+	// The line after the articles is the prompt to proceed.
+	proceedIndex := len(state.articles)
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		state.message = ""
 		switch msg.String() {
 		case "ctrl+c", "q":
 			return m, tea.Quit
+		case "o", "c":
+			if state.cursorIndex < proceedIndex {
+				i, comments := state.cursorIndex, msg.String() == "c"
+				return m.withBrowser(func(m *model) {
+					m.articleMenu.message = openArticle(&m.articleMenu.articles[i], comments)
+				})
+			}
 		// Change cursor-selected article
 		case "up", "k":
-			if state.cursorIndex > from {
+			if state.cursorIndex > 0 {
 				state.cursorIndex--
 			}
 		case "down", "j":
-			if state.cursorIndex < to {
+			if state.cursorIndex < proceedIndex {
 				state.cursorIndex++
 			}
-		// Change page
-		case "right", "l":
-			if state.pageIndex == 0 {
-				state.pageIndex++
-				state.cursorIndex = 15
-			}
-		case "left", "h":
-			if state.pageIndex == 1 {
-				state.pageIndex--
-				state.cursorIndex = 0
-			}
+		case "home", "g":
+			state.cursorIndex = 0
+		case "end", "G":
+			state.cursorIndex = proceedIndex
 		case "enter", " ":
-			if state.cursorIndex < to {
+			if state.cursorIndex < proceedIndex {
 				_, ok := state.selected[state.cursorIndex]
 				if ok {
 					delete(state.selected, state.cursorIndex)
@@ -63,9 +57,8 @@ func updateArticleMenu(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 					articles = append(articles, state.articles[i])
 				}
 
-				output := utils.BytemarksToTables(articles)
-				writtenTo, err := utils.Write(
-					m.outputVars.outputPath, output, m.outputVars.clipboardOut,
+				writtenTo, written, duplicates, err := utils.WriteBytemarks(
+					m.outputVars.outputPath, articles, m.outputVars.clipboardOut,
 				)
 				if err != nil {
 					log.Fatal(err)
@@ -76,9 +69,16 @@ func updateArticleMenu(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.promptMenu.options = []string{"Continue", "Quit"}
 				m.promptMenu.prompt = fmt.Sprintf(
 					"%d articles written to %s.\n",
-					len(articles),
+					written,
 					writtenTo,
 				)
+				for _, duplicate := range duplicates {
+					m.promptMenu.prompt += duplicate.String() + "\n"
+				}
+				for _, warning := range warnings {
+					m.promptMenu.prompt += warning + "\n"
+				}
+				warnings = nil
 			}
 		}
 	}
@@ -88,26 +88,13 @@ func updateArticleMenu(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 func articleMenuView(m model) string {
 	state := m.articleMenu
 
-	s := styles.HeaderStyle.Render("Top 30 on HackerNews")
-	s += "\n"
-
-	var from int
-	var to int
-	var page string
-	if state.pageIndex == 0 {
-		from = 0
-		to = 15
-	} else {
-		from = 15
-		to = 30
-	}
-	instr := "arrow keys/hjkl to navigate"
-	page = fmt.Sprintf("\nArticles %d-%d (%s):\n", from+1, to, instr)
-	s += page
-
-	for i := from; i < to; i++ {
-		article := state.articles[i]
+	header := styles.HeaderStyle.Render("Top 30 on HackerNews")
+	header += "\n"
+	instr := "arrow keys/jk to navigate, g/G for the first/last"
+	header += fmt.Sprintf("\nArticles 1-%d (%s):\n", len(state.articles), instr)
 
+	lines := make([]string, 0, len(state.articles))
+	for i, article := range state.articles {
 		cursor := ""
 		number := strconv.Itoa(i+1)
 		if i == state.cursorIndex {
@@ -121,14 +108,12 @@ func articleMenuView(m model) string {
 			style = styles.HighlightedCrimson
 		}
 		title := style.Render(article.Title)
-		line := fmt.Sprintf("%s%s. %s\n", cursor, number, title)
-
-		s += line
+		lines = append(lines, fmt.Sprintf("%s%s. %s", cursor, number, title))
 	}
 
 	cursor := ""
 	proceed := "Proceed?"
-	if state.cursorIndex == to {
+	if state.cursorIndex == len(state.articles) {
 		cursor = templates.Cursor()
 		proceed = styles.HRender(styles.ProtonPurple, proceed)
 	}
@@ -136,8 +121,12 @@ func articleMenuView(m model) string {
 	selected := strconv.Itoa(len(state.selected))
 	selected = styles.HighlightedBlue.Render(selected)
 
-	s += fmt.Sprintf("\n%s%s articles selected. %s", cursor, selected, proceed)
-	return s
+	footer := fmt.Sprintf("\n%s%s articles selected. %s", cursor, selected, proceed)
+	footer += fmt.Sprintf("\n%s | %s", styles.CommandInfo("Open", "o"), styles.CommandInfo("Comments", "c"))
+	if state.message != "" {
+		footer += "\n" + state.message
+	}
+	return listView(m, header, lines, state.cursorIndex, footer)
 }
 
 func updateArticlesAdded(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -158,7 +147,6 @@ func updateArticlesAdded(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		case "enter", " ":
 			if state.cursorIndex == 0 {
-				ClearScreen()
 				m.currentView = startView
 				m.Wipe()
 			} else {
@@ -4,8 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"os"
-	"strconv"
+	"strings"
 	"hypermark/frontend/styles"
 	"hypermark/frontend/templates"
 	"hypermark/urlMode"
@@ -14,14 +13,25 @@ import (
 )
 
 // Same size array. For convenience when displaying.
-func makeOtherHyperpaths(hyperpaths []string, index int) []string {
-	culled := make([]string, len(hyperpaths))
+func makeOtherHyperpaths(hyperpaths []utils.Hyperpath, index int) []utils.Hyperpath {
+	culled := make([]utils.Hyperpath, len(hyperpaths))
 	for i, hp := range hyperpaths {
 		if i != index {culled[i] = hp}
 	}
 	return culled
 }
 
+// Options for the send prompt, empty where there is no hyperpath.
+func hyperpathOptions(hyperpaths []utils.Hyperpath) []string {
+	options := make([]string, len(hyperpaths))
+	for i, hp := range hyperpaths {
+		if hp.Path != "" {
+			options[i] = fmt.Sprintf("%s: %s", hp.Name, hp.Path)
+		}
+	}
+	return options
+}
+
 func firstNonEmpty(arr []string) (string, int) {
 	for i, s := range arr {
 		if s != "" {
@@ -48,26 +58,27 @@ func updateBytemarksMenu(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 				state.cursorIndex++
 			}
 		case "enter":
+			if len(state.hyperpaths) == 0 {
+				break
+			}
+			selected := state.hyperpaths[state.cursorIndex]
 			// load bytemarks of the selected hyperpath into state.
-			var err error
-			file, err := os.OpenFile(
-				state.hyperpaths[state.cursorIndex],
-				os.O_RDWR,
-				0666,
-			)
+			collection, err := utils.LoadCollection(selected)
 			if err != nil {
-				errMsg := fmt.Sprintf("Error opening hyperpaths[%d]", state.cursorIndex)
+				errMsg := fmt.Sprintf("Error opening hyperpath[%s]: ", selected.Name)
 				errMsg += err.Error()
 				cErr := errors.New(errMsg)
 				log.Fatal(cErr)
 			}
-			m.bytemarksManager.bytemarks, err = utils.FileToBytemarks(file)
-			if err != nil {
-				log.Fatal(err)
-			}
-			file.Close()
+			m.bytemarksManager.collection = collection
+			m.bytemarksManager.bytemarks = collection.Bytemarks()
 
-			m.bytemarksManager.hyperpath = state.hyperpaths[state.cursorIndex]
+			m.bytemarksManager.hyperpath = selected
+			m.bytemarksManager.clearSelection()
+			m.bytemarksManager.clearFilter()
+			m.bytemarksManager.clearHistory()
+			m.bytemarksManager.setSaved()
+			m.bytemarksManager.pages = make(map[string]*utils.CachedPage)
 			m.bytemarksManager.otherHyperpaths = makeOtherHyperpaths(
 				state.hyperpaths,
 				state.cursorIndex,
@@ -83,64 +94,156 @@ func updateBytemarksMenu(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 func bytemarksMenuView(m model) string {
 	state := m.hyperpathsMenu
 
-	var s string
-	s += fmt.Sprintf("\n%s: %s\n\n",
-		styles.MakeHyperpathString(state.cursorIndex),
+	if len(state.hyperpaths) == 0 {
+		back := styles.CommandInfo("Go back", "esc")
+		return fmt.Sprintf("No hyperpaths to display.\n%s", back)
+	}
+
+	header := fmt.Sprintf("\n%s: %s\n\n",
+		styles.MakeHyperpathString(state.hyperpaths[state.cursorIndex].Name),
 		styles.CommandInfo("Manage bytemarks", "enter"),
 	)
 
-	for i, hyperpath := range state.hyperpaths {
+	lines := make([]string, 0, len(state.hyperpaths))
+	for i, hp := range state.hyperpaths {
 		cursor := ""
-		num := strconv.Itoa(i)
+		name := hp.Name
+		hyperpath := hp.Path
 		colon := ":"
 		if state.cursorIndex == i {
 			cursor = templates.Cursor()
 			hyperpath = styles.HRender(styles.ProtonPurple, hyperpath)
-			num = styles.HRender(styles.Crimson, num)
+			name = styles.HRender(styles.Crimson, name)
 			colon = styles.HRender(styles.OrangeRed, colon)
 		}
-		s += fmt.Sprintf("%s%s%s %s\n", cursor, num, colon, hyperpath)
+		lines = append(lines, fmt.Sprintf("%s%s%s %s", cursor, name, colon, hyperpath))
 	}
-	return s
+	return listView(m, header, lines, state.cursorIndex, "")
+}
+
+// Add a new bytemark to the end of the default file's bytemarks.
+func (state *bytemarksManager) addBytemark(bytemark utils.Bytemark) {
+	bytemark.SetFile(state.collection.DefaultFile)
+	index := len(state.bytemarks)
+	for i, b := range state.bytemarks {
+		if b.File() == bytemark.File() {
+			index = i + 1
+		}
+	}
+	state.bytemarks = utils.InsertBytemark(state.bytemarks, bytemark, index)
+}
+
+// In move mode, moving past the first or last bytemark of a file moves
+// the bytemark into the neighbouring file instead of swapping places.
+func (state *bytemarksManager) moveToFileOf(neighbor int) bool {
+	current := &state.bytemarks[state.cursorIndex]
+	file := state.bytemarks[neighbor].File()
+	if current.File() == file {
+		return false
+	}
+	current.SetFile(file)
+	return true
 }
 
 func updateBytemarksManager(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 	state := &m.bytemarksManager
+	if state.filtering {
+		return updateFilter(m, msg)
+	}
+	state.fixCursor()
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
-		case "ctrl-c", "q":
+		case "ctrl+c":
 			return m, tea.Quit
+		case "q":
+			return m, m.leaveBytemarksManager(true)
 		case "s":
 			if state.moveMode {state.moveMode = false}
 			// Switch to a prompt for saving.
 			m.promptMenu.prompt = fmt.Sprintf(
 				"Save changes to %s?",
-				state.hyperpath,
+				state.hyperpath.Path,
 			)
 			m.promptMenu.options = []string{"Save", "Cancel"}
 			m.currentView = saveChangesView
-		case "t":
-			_, sendIndex := firstNonEmpty(state.otherHyperpaths)
-			m.promptMenu.prompt = fmt.Sprintf("Send bytemark to hyperpath[%d]", sendIndex)
+		case "t", "M":
+			options := hyperpathOptions(state.otherHyperpaths)
+			_, sendIndex := firstNonEmpty(options)
+			if sendIndex == -1 || len(state.targets()) == 0 {
+				break
+			}
+			state.sendMove = msg.String() == "M"
 			m.promptMenu.cursorIndex = sendIndex
-			m.promptMenu.options = utils.Copy(state.otherHyperpaths)
+			m.promptMenu.options = options
 			m.currentView = sendBytemarkView
+		case " ":
+			if len(state.bytemarks) == 0 || state.moveMode {
+				break
+			}
+			state.toggleSelected(state.cursorIndex)
+		case "v":
+			if len(state.bytemarks) == 0 || state.moveMode {
+				break
+			}
+			state.toggleVisual()
 		case "p":
-			state.bytemarks = utils.InsertBytemark(
-				state.bytemarks,
-				state.bytemarks[state.cursorIndex],
-				state.cursorIndex,
-			)
+			targets := state.targets()
+			if len(targets) == 0 {
+				break
+			}
+			state.record()
+			for i := len(targets) - 1; i >= 0; i-- {
+				state.bytemarks = utils.InsertBytemark(
+					state.bytemarks,
+					state.bytemarks[targets[i]],
+					targets[i],
+				)
+			}
+			state.clearSelection()
 		case "d":
-			m.promptMenu.prompt = fmt.Sprintf("Delete '%s'?",
-				state.bytemarks[state.cursorIndex].Title,
+			targets := state.targets()
+			if len(targets) == 0 {
+				break
+			}
+			m.promptMenu.prompt = fmt.Sprintf("Delete %s?",
+				state.targetsString(targets),
 			)
 			m.promptMenu.options = []string{"Yes", "Cancel"}
 			m.currentView = deleteBytemarkView
 		case "m":
+			if state.query() != "" {
+				// Neighbours in the list may not be shown.
+				break
+			}
+			state.clearSelection()
 			state.moveMode = !state.moveMode
+		case "/":
+			state.startFilter()
+		case "x":
+			targets := state.targets()
+			if len(targets) == 0 {
+				break
+			}
+			state.record()
+			next := state.bytemarks[targets[0]].ReadState.Next()
+			for _, i := range targets {
+				state.bytemarks[i].SetReadState(next)
+			}
+		case "a":
+			targets := state.targets()
+			if len(targets) == 0 {
+				break
+			}
+			m.archiveBytemarks(targets)
+		case "#":
+			targets := state.targets()
+			if len(targets) == 0 {
+				break
+			}
+			m.initTagsPrompt(targets)
+			m.currentView = editTagsView
 		case "n":
 			newBytemark, err := urlMode.BytemarkFromURL()
 			if err != nil {
@@ -149,10 +252,23 @@ func updateBytemarksManager(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.promptMenu.options = []string{styles.CommandInfo("Go back", "esc")}
 				m.currentView = badURLView
 			} else {
-				state.bytemarks = append(state.bytemarks, newBytemark)
+				state.record()
+				state.addBytemark(newBytemark)
 			}
 		case "up", "k":
+			if !state.moveMode {
+				if i := state.nextVisible(state.cursorIndex, -1); i != -1 {
+					state.cursorIndex = i
+				}
+				break
+			}
 			if state.cursorIndex > 0 {
+				if state.moveMode {
+					state.record()
+				}
+				if state.moveMode && state.moveToFileOf(state.cursorIndex-1) {
+					break
+				}
 				if state.moveMode {
 					state.bytemarks = utils.SwapBytemarks(
 						state.bytemarks,
@@ -163,7 +279,19 @@ func updateBytemarksManager(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 				state.cursorIndex--
 			}
 		case "down", "j":
+			if !state.moveMode {
+				if i := state.nextVisible(state.cursorIndex, 1); i != -1 {
+					state.cursorIndex = i
+				}
+				break
+			}
 			if state.cursorIndex < len(state.bytemarks)-1 {
+				if state.moveMode {
+					state.record()
+				}
+				if state.moveMode && state.moveToFileOf(state.cursorIndex+1) {
+					break
+				}
 				if state.moveMode {
 					state.bytemarks = utils.SwapBytemarks(
 						state.bytemarks,
@@ -173,27 +301,95 @@ func updateBytemarksManager(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				state.cursorIndex++
 			}
+		case "u":
+			state.undo()
+		case "ctrl+r":
+			state.redo()
+		case "i":
+			state.hideDetail = !state.hideDetail
+		case "e", "E":
+			if len(state.bytemarks) == 0 || !state.visible(state.cursorIndex) {
+				break
+			}
+			if msg.String() == "E" {
+				return m.suspendForEditor(state.cursorIndex, state.bytemarks[state.cursorIndex])
+			}
+			m.initEditForm(state.cursorIndex)
+			m.currentView = editBytemarkView
+			return m, nil
+		case "o", "c":
+			targets := state.targets()
+			if len(targets) == 0 {
+				break
+			}
+			comments := msg.String() == "c"
+			return m.withBrowser(func(m *model) {
+				m.openBytemarks(targets, comments)
+			})
 		case "esc":
-			state.cursorIndex = 0
-			m.currentView = bytemarksMainView
+			if state.visual || len(state.selected) != 0 {
+				state.clearSelection()
+				break
+			}
+			if state.query() != "" {
+				state.clearFilter()
+				break
+			}
+			return m, m.leaveBytemarksManager(false)
 		}
 	}
+	state.fixCursor()
 	return m, nil
 }
 
+// Tables in the hyperpath that could not be loaded as bytemarks.
+func quarantinedView(collection *utils.Collection) string {
+	if collection == nil || len(collection.Quarantined()) == 0 {
+		return ""
+	}
+
+	s := fmt.Sprintf("\n%s\n",
+		styles.HRender(styles.Crimson, "Could not load (kept as is):"),
+	)
+	for _, q := range collection.Quarantined() {
+		title := strings.SplitN(q.Text, "\n", 2)[0]
+		file := ""
+		if len(q.Diagnostics) > 0 {
+			file = q.Diagnostics[0].Path + " "
+		}
+		s += fmt.Sprintf("%sline %d: %s\n", file, q.Line, title)
+		for _, d := range q.Diagnostics {
+			s += fmt.Sprintf("    %d:%d %s\n", d.Line, d.Column,
+				styles.HRender(styles.OrangeRed, d.Reason),
+			)
+		}
+	}
+	return s
+}
+
 func bytemarksManagerView(m model) string {
 	state := m.bytemarksManager
 
+	modified := ""
+	if state.modified() {
+		modified = styles.HRender(styles.OrangeRed, " [modified]")
+	}
 	if len(state.bytemarks) == 0 {
 		back := styles.CommandInfo("Go back", "esc")
-		return fmt.Sprintf("No bytemarks to display.\n%s", back)
+		return fmt.Sprintf("No bytemarks to display.%s\n%s%s",
+			modified,
+			quarantinedView(state.collection),
+			back,
+		)
 	}
 
-	var s string
-	s += fmt.Sprintf("%s: %s\n\n",
+	state.fixCursor()
+	header := fmt.Sprintf("%s: %s%s\n\n",
 		styles.HRender(styles.AquaMenthe, "bytemarks"),
-		styles.StylePath(state.hyperpath),
+		styles.StylePath(state.hyperpath.Path),
+		modified,
 	)
+	header += filterView(state)
 	var move string
 	if !state.moveMode {
 		move = fmt.Sprintf(" | %s", styles.CommandInfo("Move", "m"))
@@ -201,35 +397,89 @@ func bytemarksManagerView(m model) string {
 		move = fmt.Sprintf(" | %s", styles.CommandInfo("Drop", "m"))
 	}
 
+	// Directory and glob hyperpaths show which file each bytemark is in.
+	grouped := state.hyperpath.IsMulti()
+	shown := -1 // The last bytemark shown.
+	lines := make([]string, 0, len(state.bytemarks))
+	cursorLine := 0
 	for i, bytemark := range state.bytemarks {
-		title := bytemark.Title
+		match, ok := bytemark.Search(state.query())
+		if !ok {
+			continue
+		}
+		if grouped && (shown == -1 || state.bytemarks[shown].File() != bytemark.File()) {
+			file := bytemark.File()
+			if file == "" {
+				file = state.collection.DefaultFile
+			}
+			if shown != -1 {
+				lines = append(lines, "")
+			}
+			lines = append(lines, styles.StylePath(file))
+		}
+		shown = i
+
+		title := highlight(bytemark.Title, match.Title)
+		if state.isSelected(i) {
+			title = styles.HighlightedCrimson.Render(title)
+		}
 		cursor := ""
 		if state.cursorIndex == i {
 			cursor = templates.Cursor()
+			cursorLine = len(lines)
 			if state.moveMode {
 				title = styles.HRender(styles.OrangeRed, title)
 			} else {
 				title = styles.HRender(styles.ProtonPurple, title)
 			}
 		}
-		s += fmt.Sprintf("%s%s\n", cursor, title)
+		lines = append(lines, fmt.Sprintf("%s%s%s%s",
+			cursor,
+			readStateMarker(bytemark),
+			title,
+			tagsString(bytemark),
+		))
+		if match.Field != "" {
+			lines = append(lines, fmt.Sprintf("      %s: %s", match.Field,
+				highlight(match.Text, match.Positions)))
+		}
 	}
+	if shown == -1 {
+		lines = append(lines, "No bytemarks match.")
+	}
+	footer := quarantinedView(state.collection)
 
 	save := styles.CommandInfo("Save", "s")
 	dup := styles.CommandInfo("Duplicate", "p")
 	send := styles.CommandInfo("Send to", "t")
+	moveTo := styles.CommandInfo("Move to", "M")
 	del := styles.CommandInfo("Delete", "d")
-	s += fmt.Sprintf("\n%s   | %s | %s\n%s%s      | %s\n%s\n",
+	tags := styles.CommandInfo("Tags", "#")
+	footer += fmt.Sprintf("\n%s   | %s | %s | %s\n%s%s      | %s\n%s     | %s | %s\n%s | %s | %s\n%s | %s | %s | %s\n%s | %s | %s | %s\n",
 		save,
 		dup,
 		send,
+		moveTo,
 		del,
 		move,
 		styles.CommandInfo("Create bytemark", "n"),
+		tags,
+		styles.CommandInfo("Read state", "x"),
+		styles.CommandInfo("Read & archive", "a"),
+		styles.CommandInfo("Select", "space"),
+		styles.CommandInfo("Select range", "v"),
 		styles.CommandInfo("Go back", "esc"),
+		styles.CommandInfo("Undo", "u"),
+		styles.CommandInfo("Redo", "ctrl+r"),
+		styles.CommandInfo("Filter", "/"),
+		styles.CommandInfo("Details", "i"),
+		styles.CommandInfo("Open", "o"),
+		styles.CommandInfo("Open comments", "c"),
+		styles.CommandInfo("Edit", "e"),
+		styles.CommandInfo("Edit in $EDITOR", "E"),
 	)
 
-	return s
+	return detailView(m, header, lines, cursorLine, footer)
 }
 
 func updateSaveChanges(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -254,44 +504,135 @@ func updateSaveChanges(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		case "enter":
 			if stateA.cursorIndex == 0 {
-				output := utils.BytemarksToTables(stateB.bytemarks)
-				if err := os.Remove(stateB.hyperpath); err != nil {
-					log.Fatal(err)
-				}
-				selectedFile, err := os.OpenFile(
-					stateB.hyperpath,
-					os.O_CREATE|os.O_RDWR,
-					0666,
-				)
-				if err != nil {
-					log.Fatal(err)
-				}
-				_, err = utils.Write(
-					selectedFile,
-					output,
-					m.outputVars.clipboardOut,
-				)
-				if err != nil {
+				// Only the bytemarks are rewritten; anything else the
+				// user keeps in the file is left as it was.
+				stateB.collection.SetBytemarks(stateB.bytemarks)
+				err := stateB.collection.Save()
+				if errors.Is(err, utils.ErrConflict) {
+					m.setPrompt(
+						fmt.Sprintf("%s was changed since it was loaded.", stateB.hyperpath.Path),
+						[]string{"Merge", "Reload", "Overwrite", "Cancel"},
+					)
+					stateA.cursorIndex = 0
+					m.currentView = saveConflictView
+					return m, nil
+				} else if err != nil {
 					log.Fatal(err)
 				}
-				selectedFile.Close()
+				m.reloadBytemarks()
+			}
+			m.wipePromptMenu()
+			m.currentView = byteManagerView
+		}
+	}
+	return m, nil
+}
 
-				file, err := os.OpenFile(
-					stateB.hyperpath,
-					os.O_RDWR,
-					0666,
-				)
-				if err != nil {
-					log.Fatal(err)
-				}
-				m.bytemarksManager.bytemarks, err = utils.FileToBytemarks(file)
-				if err != nil {
+// Load the bytemarks of the hyperpath being managed from disk again.
+func (m *model) reloadBytemarks() {
+	state := &m.bytemarksManager
+
+	collection, err := utils.LoadCollection(state.hyperpath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	state.collection = collection
+	state.bytemarks = collection.Bytemarks()
+	if state.cursorIndex >= len(state.bytemarks) {
+		state.cursorIndex = 0
+	}
+	state.clearSelection()
+	state.clearHistory()
+	state.setSaved()
+	state.pages = make(map[string]*utils.CachedPage)
+	if err := m.syncOutputVars(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// Move the bytemarks at targets to dst, as moved. Only the targets are
+// taken out of the files on disk; any other changes to the hyperpath being
+// managed stay unsaved. Nothing changes if the move fails.
+func (m *model) moveTargets(targets []int, moved []utils.Bytemark, dst *utils.Collection) ([]utils.Duplicate, error) {
+	state := &m.bytemarksManager
+
+	onDisk := make([]utils.Bytemark, 0, len(state.saved))
+	for _, saved := range state.saved {
+		target := false
+		for _, i := range targets {
+			if state.bytemarks[i].SameOrigin(saved) {
+				target = true
+			}
+		}
+		if !target {
+			onDisk = append(onDisk, saved)
+		}
+	}
+	duplicates, err := utils.MoveBytemarks(state.collection, onDisk, dst, moved)
+	if err != nil {
+		state.collection.SetBytemarks(state.saved)
+		return nil, err
+	}
+
+	cursor := targets[0]
+	state.bytemarks = utils.DeleteBytemarks(state.bytemarks, targets)
+	state.saved = onDisk
+	if cursor >= len(state.bytemarks) {
+		cursor = len(state.bytemarks) - 1
+	}
+	state.cursorIndex = cursor
+	state.fixCursor()
+	state.clearSelection()
+	// An undo would bring back the moved bytemarks as well.
+	state.clearHistory()
+	if err := m.syncOutputVars(); err != nil {
+		log.Fatal(err)
+	}
+	return duplicates, nil
+}
+
+// The hyperpath was changed by something else, eg: hypermark -k, while
+// it was being managed.
+func updateSaveConflict(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	stateA := &m.promptMenu
+	stateB := &m.bytemarksManager
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q":
+			return m, tea.Quit
+		case "esc":
+			m.wipePromptMenu()
+			m.currentView = byteManagerView
+		case "up", "k":
+			if stateA.cursorIndex > 0 {
+				stateA.cursorIndex--
+			}
+		case "down", "j":
+			if stateA.cursorIndex < len(stateA.options)-1 {
+				stateA.cursorIndex++
+			}
+		case "enter":
+			switch stateA.cursorIndex {
+			case 0: // Merge
+				if err := stateB.collection.Merge(); err != nil {
 					log.Fatal(err)
 				}
-				file.Close()
-				if err := m.syncOutputVars(); err != nil {
+				stateB.bytemarks = stateB.collection.Bytemarks()
+				m.wipePromptMenu()
+				m.setPrompt(fmt.Sprintf("Save changes to %s?", stateB.hyperpath.Path),
+					[]string{"Save", "Cancel"},
+				)
+				m.currentView = saveChangesView
+				return m, nil
+			case 1: // Reload
+				m.reloadBytemarks()
+			case 2: // Overwrite
+				if err := stateB.collection.Overwrite(); err != nil {
 					log.Fatal(err)
 				}
+				m.reloadBytemarks()
 			}
 			m.wipePromptMenu()
 			m.currentView = byteManagerView
@@ -321,12 +662,13 @@ func updateDeleteBytemark(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 				stateA.cursorIndex++
 			}
 		case "enter":
-			if stateA.cursorIndex == 0 {
-				stateB.bytemarks = utils.DeleteBytemark(
-					stateB.bytemarks,
-					stateB.cursorIndex,
-				)
+			targets := stateB.targets()
+			if stateA.cursorIndex == 0 && len(targets) != 0 {
+				stateB.record()
+				stateB.bytemarks = utils.DeleteBytemarks(stateB.bytemarks, targets)
+				stateB.cursorIndex = targets[0]
 				if stateB.cursorIndex > 0 {stateB.cursorIndex--}
+				stateB.clearSelection()
 			}
 			m.wipePromptMenu()
 			m.currentView = byteManagerView
@@ -362,7 +704,7 @@ func updateSendBytemark(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 			if stateA.cursorIndex < len(stateA.options)-1 {
 				stateA.cursorIndex++
 				if stateA.options[stateA.cursorIndex] == "" {
-					if stateA.cursorIndex + 1 <= len(stateA.options) {
+					if stateA.cursorIndex + 1 < len(stateA.options) {
 						stateA.cursorIndex++
 					} else {
 						stateA.cursorIndex--
@@ -370,23 +712,46 @@ func updateSendBytemark(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 		case "enter":
-			writeTo, err := utils.GetFile(stateA.options[stateA.cursorIndex], false)
-			if err != nil {
-				log.Fatal(err)
+			targets := stateB.targets()
+			if len(targets) == 0 {
+				m.wipePromptMenu()
+				m.currentView = byteManagerView
+				break
 			}
-			bytemark := stateB.bytemarks[stateB.cursorIndex]
-			_, err = utils.Write(writeTo, bytemark.Table(), false)
+			target := stateB.otherHyperpaths[stateA.cursorIndex]
+			collection, err := utils.LoadCollection(target)
 			if err != nil {
 				log.Fatal(err)
 			}
+			what := stateB.targetsString(targets)
+			sent := make([]utils.Bytemark, 0, len(targets))
+			for _, i := range targets {
+				sent = append(sent, stateB.bytemarks[i])
+			}
 
-			m.promptMenu.prompt = fmt.Sprintf(
-				"Sent bytemark to %s",
-				stateA.options[stateA.cursorIndex],
-			)
-			m.promptMenu.options = []string{"Okay"}
-			m.promptMenu.cursorIndex = 0
-			m.currentView = sentConfirmationView
+			var duplicates []utils.Duplicate
+			verb := "Sent"
+			if stateB.sendMove {
+				verb = "Moved"
+				duplicates, err = m.moveTargets(targets, sent, collection)
+				if err != nil {
+					m.showMessage("Nothing was moved: " + err.Error())
+					return m, nil
+				}
+			} else {
+				duplicates, err = collection.AddBytemarks(sent)
+				if err != nil {
+					log.Fatal(err)
+				}
+				stateB.clearSelection()
+			}
+
+			message := fmt.Sprintf("%s %s to %s (%s)",
+				verb, what, target.Name, collection.DefaultFile)
+			for _, duplicate := range duplicates {
+				message += "\n" + duplicate.String()
+			}
+			m.showMessage(message)
 		}
 	}
 	return m, nil
@@ -394,26 +759,34 @@ func updateSendBytemark(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func sendBytemarkPromptView(m model) string {
 	stateA := m.promptMenu
-	//stateB := m.bytemarksManager
+	stateB := m.bytemarksManager
 
-	var s string
-	s += fmt.Sprintf("%s %s %s\n\n",
-		styles.HRender(styles.Crimson, "Send bytemark to"),
-		styles.MakeHyperpathString(stateA.cursorIndex),
+	verb := "Send"
+	if stateB.sendMove {
+		verb = "Move"
+	}
+	header := fmt.Sprintf("%s %s %s\n\n",
+		styles.HRender(styles.Crimson, fmt.Sprintf("%s %s to", verb,
+			stateB.targetsString(stateB.targets()))),
+		styles.MakeHyperpathString(stateB.otherHyperpaths[stateA.cursorIndex].Name),
 		styles.KeyStyle("enter"),
 	)
+
+	lines := make([]string, 0, len(stateA.options))
+	cursorLine := 0
 	for i, hyperpath := range stateA.options {
 		if hyperpath != "" {
 			cursor := ""
 			if stateA.cursorIndex == i {
 				cursor = templates.Cursor()
 				hyperpath = styles.HRender(styles.ProtonPurple, hyperpath)
+				cursorLine = len(lines)
 			}
-			s += fmt.Sprintf("%s%s\n", cursor, hyperpath)
+			lines = append(lines, fmt.Sprintf("%s%s", cursor, hyperpath))
 		}
 	}
-	s += fmt.Sprintf("\n%s\n", styles.CommandInfo("Go back", "esc"))
-	return s
+	footer := fmt.Sprintf("\n%s\n", styles.CommandInfo("Go back", "esc"))
+	return listView(m, header, lines, cursorLine, footer)
 }
 
 func updateSentConfirmation(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
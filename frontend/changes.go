@@ -0,0 +1,222 @@
+package frontend
+
+import (
+	"errors"
+	"fmt"
+	"hypermark/frontend/styles"
+	"hypermark/utils"
+	"log"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Unchanged lines shown around each change in the diff preview.
+const diffContext = 2
+
+// Whether the bytemarks were changed since they were loaded or saved.
+func (state *bytemarksManager) modified() bool {
+	if len(state.bytemarks) != len(state.saved) {
+		return true
+	}
+	for i := range state.bytemarks {
+		if state.bytemarks[i].File() != state.saved[i].File() ||
+			state.bytemarks[i].Table() != state.saved[i].Table() {
+			return true
+		}
+	}
+	return false
+}
+
+func (state *bytemarksManager) setSaved() {
+	state.saved = make([]utils.Bytemark, len(state.bytemarks))
+	copy(state.saved, state.bytemarks)
+}
+
+// Leave the manager, back to the hyperpaths or out of hypermark. Asks
+// what to do with the changes first, if there are any.
+func (m *model) leaveBytemarksManager(quit bool) tea.Cmd {
+	state := &m.bytemarksManager
+
+	state.quitting = quit
+	if state.modified() {
+		state.moveMode = false
+		m.setPrompt(
+			fmt.Sprintf("%s has unsaved changes.", state.hyperpath.Path),
+			[]string{"Save", "Discard", "Show changes", "Cancel"},
+		)
+		m.promptMenu.cursorIndex = 0
+		m.currentView = leaveManagerView
+		return nil
+	}
+	return m.leave()
+}
+
+func (m *model) leave() tea.Cmd {
+	m.wipePromptMenu()
+	if m.bytemarksManager.quitting {
+		return tea.Quit
+	}
+	m.bytemarksManager.cursorIndex = 0
+	m.bytemarksManager.clearSelection()
+	m.currentView = bytemarksMainView
+	return nil
+}
+
+func updateLeaveManager(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	stateA := &m.promptMenu
+	stateB := &m.bytemarksManager
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "esc":
+			m.wipePromptMenu()
+			m.currentView = byteManagerView
+		case "up", "k":
+			if stateA.cursorIndex > 0 {
+				stateA.cursorIndex--
+			}
+		case "down", "j":
+			if stateA.cursorIndex < len(stateA.options)-1 {
+				stateA.cursorIndex++
+			}
+		case "enter":
+			switch stateA.cursorIndex {
+			case 0: // Save
+				stateB.collection.SetBytemarks(stateB.bytemarks)
+				err := stateB.collection.Save()
+				if errors.Is(err, utils.ErrConflict) {
+					m.setPrompt(
+						fmt.Sprintf("%s was changed since it was loaded.", stateB.hyperpath.Path),
+						[]string{"Merge", "Reload", "Overwrite", "Cancel"},
+					)
+					stateA.cursorIndex = 0
+					m.currentView = saveConflictView
+					return m, nil
+				} else if err != nil {
+					log.Fatal(err)
+				}
+				m.reloadBytemarks()
+				return m, m.leave()
+			case 1: // Discard
+				return m, m.leave()
+			case 2: // Show changes
+				stateB.collection.SetBytemarks(stateB.bytemarks)
+				changes, err := stateB.collection.Changes()
+				if err != nil {
+					log.Fatal(err)
+				}
+				stateB.changes = changes
+				m.initChangesViewport()
+				m.currentView = changesView
+			case 3: // Cancel
+				m.wipePromptMenu()
+				m.currentView = byteManagerView
+			}
+		}
+	}
+	return m, nil
+}
+
+// Lines of the changes view that are not the changes.
+const changesChrome = 4
+
+// The rest of the terminal, or all of the changes if its size is unknown.
+func changesHeight(height int) int {
+	if height == 0 {
+		return 0
+	}
+	if height-changesChrome < 1 {
+		return 1
+	}
+	return height - changesChrome
+}
+
+func (m *model) initChangesViewport() {
+	state := &m.bytemarksManager
+
+	text := changesText(state.changes)
+	state.changesViewport = viewport.Model{
+		Width:  m.width,
+		Height: changesHeight(m.height),
+	}
+	if state.changesViewport.Height == 0 {
+		state.changesViewport.Height = countLines(text)
+	}
+	state.changesViewport.SetContent(text)
+}
+
+func updateChanges(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "esc", "enter", "q":
+			m.bytemarksManager.changes = nil
+			m.currentView = leaveManagerView
+			return m, nil
+		}
+	}
+	m.bytemarksManager.changesViewport, cmd = m.bytemarksManager.changesViewport.Update(msg)
+	return m, cmd
+}
+
+func changesMenuView(m model) string {
+	return fmt.Sprintf("%s\n\n%s\n%s | %s\n",
+		styles.HRender(styles.Crimson, "Changes to be saved"),
+		m.bytemarksManager.changesViewport.View(),
+		styles.CommandInfo("Scroll", "j/k"),
+		styles.CommandInfo("Go back", "esc"),
+	)
+}
+
+// The changed lines of each file, with a few unchanged lines around them.
+func changesText(changes []utils.FileDiff) string {
+	var s string
+	if len(changes) == 0 {
+		s += "The files would not change.\n"
+	}
+	for _, change := range changes {
+		s += styles.StylePath(change.Path) + "\n"
+
+		near := make([]bool, len(change.Lines))
+		for i, line := range change.Lines {
+			if line.Kind == utils.DiffSame {
+				continue
+			}
+			for j := i - diffContext; j <= i+diffContext; j++ {
+				if j >= 0 && j < len(near) {
+					near[j] = true
+				}
+			}
+		}
+		skipped := false
+		for i, line := range change.Lines {
+			if !near[i] {
+				if !skipped {
+					s += "  ...\n"
+				}
+				skipped = true
+				continue
+			}
+			skipped = false
+			text := fmt.Sprintf("%c %s", line.Kind, line.Text)
+			switch line.Kind {
+			case utils.DiffRemoved:
+				text = styles.HRender(styles.Crimson, text)
+			case utils.DiffAdded:
+				text = styles.HRender(styles.AquaMenthe, text)
+			}
+			s += text + "\n"
+		}
+		s += "\n"
+	}
+	return strings.TrimSuffix(s, "\n")
+}
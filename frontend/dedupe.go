@@ -0,0 +1,130 @@
+package frontend
+
+import (
+	"fmt"
+	"hypermark/frontend/styles"
+	"hypermark/frontend/templates"
+	"hypermark/utils"
+	"log"
+	"strconv"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Look for duplicates across every hyperpath.
+func (m *model) findDuplicates() {
+	collections, err := utils.LoadAllCollections()
+	if err != nil {
+		log.Fatal(err)
+	}
+	m.dedupeMenu.groups = utils.FindDuplicates(collections)
+	m.dedupeMenu.selected = make(map[int]struct{})
+	m.dedupeMenu.cursorIndex = 0
+}
+
+func updateDedupe(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	state := &m.dedupeMenu
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q":
+			return m, tea.Quit
+		case "esc":
+			m.dedupeMenu = dedupeMenu{}
+			m.currentView = startView
+		case "up", "k":
+			if state.cursorIndex > 0 {
+				state.cursorIndex--
+			}
+		case "down", "j":
+			if state.cursorIndex < len(state.groups)-1 {
+				state.cursorIndex++
+			}
+		case " ":
+			if len(state.groups) == 0 {
+				break
+			}
+			if _, ok := state.selected[state.cursorIndex]; ok {
+				delete(state.selected, state.cursorIndex)
+			} else {
+				state.selected[state.cursorIndex] = struct{}{}
+			}
+		case "a":
+			if len(state.selected) == len(state.groups) {
+				state.selected = make(map[int]struct{})
+			} else {
+				for i := range state.groups {
+					state.selected[i] = struct{}{}
+				}
+			}
+		case "enter":
+			if len(state.selected) == 0 {
+				break
+			}
+			groups := make([]utils.DuplicateGroup, 0, len(state.selected))
+			for i, group := range state.groups {
+				if _, ok := state.selected[i]; ok {
+					groups = append(groups, group)
+				}
+			}
+			state.footer = fmt.Sprintf("Merged %d groups.", len(groups))
+			if err := utils.MergeDuplicates(groups); err != nil {
+				state.footer = fmt.Sprintf("Could not merge: %v", err)
+			}
+			footer := state.footer
+			m.findDuplicates()
+			state.footer = footer
+		}
+	}
+	return m, nil
+}
+
+func dedupeMenuView(m model) string {
+	state := m.dedupeMenu
+
+	header := styles.HeaderStyle.Render("Duplicates")
+	header += "\n\n"
+	if len(state.groups) == 0 {
+		header += "No duplicates found.\n"
+	}
+	lines := make([]string, 0)
+	cursorLine := 0
+	for i, group := range state.groups {
+		cursor := "   "
+		number := strconv.Itoa(i + 1)
+		if i == state.cursorIndex {
+			cursor = templates.Cursor()
+			number = styles.HRender(styles.JustBlue, number)
+			cursorLine = len(lines)
+		}
+		style := lipgloss.NewStyle()
+		if _, ok := state.selected[i]; ok {
+			style = styles.HighlightedCrimson
+		}
+		lines = append(lines, fmt.Sprintf("%s%s.", cursor, number))
+		for _, location := range group {
+			lines = append(lines,
+				fmt.Sprintf("    %s: %s",
+					styles.MakeHyperpathString(location.Collection.Hyperpath.Name),
+					style.Render(location.Bytemark.Title),
+				),
+				fmt.Sprintf("      %s", location.Bytemark.RootURL),
+			)
+		}
+	}
+
+	var footer string
+	if state.footer != "" {
+		footer += "\n" + state.footer + "\n"
+	}
+
+	footer += fmt.Sprintf("\n%s | %s | %s\n%s\n",
+		styles.CommandInfo("Select", "space"),
+		styles.CommandInfo("Select all", "a"),
+		styles.CommandInfo("Merge selected", "enter"),
+		styles.CommandInfo("Go back", "esc"),
+	)
+	return listView(m, header, lines, cursorLine, footer)
+}
@@ -0,0 +1,129 @@
+package frontend
+
+import (
+	"fmt"
+	"hypermark/frontend/styles"
+	"hypermark/utils"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The detail pane goes to the right of the list when the terminal is at
+// least this wide, and below it otherwise.
+const detailSideWidth = 100
+
+var detailBorder = lipgloss.NewStyle().
+	Border(lipgloss.NormalBorder(), false, false, false, true).
+	BorderForeground(lipgloss.Color(styles.PalestBlue)).
+	PaddingLeft(1)
+
+// The cached page of url, or nil. Pages are read from the cache once per
+// hyperpath, since the view is drawn after every key.
+func (state *bytemarksManager) cachedPage(url string) *utils.CachedPage {
+	if page, ok := state.pages[url]; ok {
+		return page
+	}
+	var page *utils.CachedPage
+	if cached, ok, err := utils.ReadCachedPage(url); err == nil && ok {
+		page = &cached
+	}
+	if state.pages != nil {
+		state.pages[url] = page
+	}
+	return page
+}
+
+func detailField(label, value string) string {
+	return fmt.Sprintf("%s %s", styles.HRender(styles.AquaMenthe, label+":"), value)
+}
+
+// Every field of b, then what was cached when its page was fetched.
+func detailText(b utils.Bytemark, page *utils.CachedPage) string {
+	lines := []string{
+		styles.HRender(styles.ProtonPurple, b.Title),
+		"",
+		detailField("url", b.RootURL),
+		detailField("date", b.DateTime),
+	}
+	for _, field := range b.Fields() {
+		lines = append(lines, detailField(field.Label, field.Value))
+	}
+	if b.File() != "" {
+		lines = append(lines, detailField("file", b.File()))
+	}
+	for _, row := range b.Rows {
+		lines = append(lines, row)
+	}
+
+	if page == nil {
+		lines = append(lines, "", styles.HRender(styles.PalestBlue, "Page not cached."))
+		return strings.Join(lines, "\n")
+	}
+	lines = append(lines, "", styles.HRender(styles.PalestBlue,
+		fmt.Sprintf("Cached %s", page.Fetched.Format("1/2/2006 15:04"))))
+	if page.Title != "" && page.Title != b.Title {
+		lines = append(lines, detailField("title", page.Title))
+	}
+	if page.Page != b.Page {
+		for _, field := range page.Page.Fields() {
+			lines = append(lines, detailField(field.Label, field.Value))
+		}
+	}
+	if page.Excerpt != "" {
+		lines = append(lines, "", page.Excerpt)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// The detail pane of the bytemark at the cursor, wrapped to width and cut
+// down to height lines when height is not 0.
+func detailPane(state *bytemarksManager, width, height int) []string {
+	b := state.bytemarks[state.cursorIndex]
+	text := detailText(b, state.cachedPage(b.RootURL))
+	if width > 0 {
+		text = lipgloss.NewStyle().Width(width).Render(text)
+	}
+	lines := strings.Split(text, "\n")
+	if height > 0 && len(lines) > height {
+		lines = lines[:height]
+	}
+	return lines
+}
+
+// The manager's list with the detail pane beside it on wide terminals, or
+// between the list and the footer on narrow ones.
+func detailView(m model, header string, lines []string, cursor int, footer string) string {
+	state := &m.bytemarksManager
+	if state.hideDetail || len(state.bytemarks) == 0 || !state.visible(state.cursorIndex) {
+		return listView(m, header, lines, cursor, footer)
+	}
+
+	if m.width >= detailSideWidth && m.height > 0 {
+		height := m.height - countLines(header) - countLines(footer)
+		list := m
+		list.width = m.width * 11 / 20
+		body := scrollLines(list, lines, cursor, height)
+		body = lipgloss.NewStyle().Width(list.width).Render(strings.TrimSuffix(body, "\n"))
+
+		// The border and its padding take 2 columns.
+		pane := detailPane(state, m.width-list.width-3, height)
+		return header + lipgloss.JoinHorizontal(lipgloss.Top,
+			body,
+			" "+detailBorder.Render(strings.Join(pane, "\n")),
+		) + "\n" + footer
+	}
+
+	var pane []string
+	if m.height > 0 {
+		pane = detailPane(state, m.width, m.height/3)
+	} else {
+		pane = detailPane(state, m.width, 0)
+	}
+	rule := styles.HRender(styles.PalestBlue, strings.Repeat("─", 20))
+	if m.width > 0 {
+		rule = styles.HRender(styles.PalestBlue, strings.Repeat("─", m.width))
+	}
+	return listView(m, header, lines, cursor,
+		rule+"\n"+strings.Join(pane, "\n")+"\n"+footer)
+}
@@ -0,0 +1,177 @@
+package frontend
+
+import (
+	"fmt"
+	"hypermark/frontend/styles"
+	"hypermark/utils"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// The fields of the edit form before its rows.
+var editLabels = []string{"title", "url", "tags", "notes"}
+
+const rowLabel = "row"
+
+func (m *model) newEditField(label, value string) promptAndTextInput {
+	ti := textinput.NewModel()
+	ti.Prompt = ""
+	ti.SetValue(value)
+	ti.CursorEnd()
+	ti.Width = 60
+	if m.width > 20 && m.width-10 < ti.Width {
+		ti.Width = m.width - 10
+	}
+	return promptAndTextInput{textInput: ti, prompt: label}
+}
+
+// Fill the form with the fields of the bytemark at index.
+func (m *model) initEditForm(index int) {
+	edit := m.bytemarksManager.bytemarks[index].Editable()
+	values := []string{edit.Title, edit.URL, edit.Tags, edit.Notes}
+
+	form := editForm{index: index}
+	for i, label := range editLabels {
+		form.fields = append(form.fields, m.newEditField(label, values[i]))
+	}
+	for _, row := range edit.Rows {
+		form.fields = append(form.fields, m.newEditField(rowLabel, row))
+	}
+	form.fields = append(form.fields, m.newEditField(rowLabel, ""))
+	form.fields[0].textInput.Focus()
+	m.editForm = form
+}
+
+func (form *editForm) setFocus(i int) {
+	if i < 0 || i >= len(form.fields) {
+		return
+	}
+	form.fields[form.focus].textInput.Blur()
+	form.focus = i
+	form.fields[form.focus].textInput.Focus()
+}
+
+// What the form holds, as an edit of the bytemark.
+func (form *editForm) edit() utils.BytemarkEdit {
+	edit := utils.BytemarkEdit{
+		Title: form.fields[0].textInput.Value(),
+		URL:   form.fields[1].textInput.Value(),
+		Tags:  form.fields[2].textInput.Value(),
+		Notes: form.fields[3].textInput.Value(),
+	}
+	for _, field := range form.fields[len(editLabels):] {
+		edit.Rows = append(edit.Rows, field.textInput.Value())
+	}
+	return edit
+}
+
+// The bytemark being edited with the form applied to it.
+func (m *model) editedBytemark() (utils.Bytemark, error) {
+	bytemark := m.bytemarksManager.bytemarks[m.editForm.index]
+	err := bytemark.ApplyEdit(m.editForm.edit())
+	return bytemark, err
+}
+
+// Put bytemark in place of the one at index, if it changed.
+func (state *bytemarksManager) replace(index int, bytemark utils.Bytemark) {
+	if bytemark.Table() == state.bytemarks[index].Table() {
+		return
+	}
+	state.record()
+	state.bytemarks[index] = bytemark
+}
+
+func updateEditBytemark(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	form := &m.editForm
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		form.err = ""
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "esc":
+			m.currentView = byteManagerView
+			return m, nil
+		case "tab", "down":
+			form.setFocus(form.focus + 1)
+			return m, nil
+		case "shift+tab", "up":
+			form.setFocus(form.focus - 1)
+			return m, nil
+		case "enter":
+			bytemark, err := m.editedBytemark()
+			if err != nil {
+				form.err = err.Error()
+				return m, nil
+			}
+			m.bytemarksManager.replace(form.index, bytemark)
+			m.currentView = byteManagerView
+			return m, nil
+		case "ctrl+o":
+			bytemark, err := m.editedBytemark()
+			if err != nil {
+				form.err = err.Error()
+				return m, nil
+			}
+			return m.suspendForEditor(form.index, bytemark)
+		}
+	}
+
+	field := &form.fields[form.focus]
+	field.textInput, cmd = field.textInput.Update(msg)
+	// There is always an empty row at the end to add another.
+	if last := form.fields[len(form.fields)-1]; last.textInput.Value() != "" {
+		form.fields = append(form.fields, m.newEditField(rowLabel, ""))
+	}
+	return m, cmd
+}
+
+// Stop the program so that bytemark can be edited in $EDITOR, and put it
+// at index in the manager once the program is started again.
+func (m model) suspendForEditor(index int, bytemark utils.Bytemark) (tea.Model, tea.Cmd) {
+	m.editForm.index = index
+	m.editForm.editing = bytemark
+	m.currentView = byteManagerView
+	return m.suspend((*model).finishEditorEdit)
+}
+
+func (m *model) finishEditorEdit() {
+	bytemark := m.editForm.editing
+	if err := bytemark.EditInEditor(); err != nil {
+		m.showMessage(fmt.Sprintf("The bytemark was not changed.\n%v", err))
+		return
+	}
+	m.bytemarksManager.replace(m.editForm.index, bytemark)
+}
+
+func editBytemarkMenuView(m model) string {
+	form := m.editForm
+
+	header := fmt.Sprintf("%s %s\n\n",
+		styles.HRender(styles.Crimson, "Edit"),
+		m.bytemarksManager.bytemarks[form.index].Title,
+	)
+	lines := make([]string, 0, len(form.fields))
+	for i, field := range form.fields {
+		label := styles.HRender(styles.AquaMenthe, fmt.Sprintf("%-6s", field.prompt))
+		if i == form.focus {
+			label = styles.HRender(styles.Crimson, fmt.Sprintf("%-6s", field.prompt))
+		}
+		lines = append(lines, fmt.Sprintf("%s %s", label, field.textInput.View()))
+	}
+
+	footer := "\n"
+	if form.err != "" {
+		footer += styles.HRender(styles.OrangeRed, form.err) + "\n\n"
+	}
+	footer += fmt.Sprintf("%s | %s\n%s | %s\n",
+		styles.CommandInfo("Next field", "tab"),
+		styles.CommandInfo("Save", "enter"),
+		styles.CommandInfo("Edit in $EDITOR", "ctrl+o"),
+		styles.CommandInfo("Cancel", "esc"),
+	)
+	return listView(m, header, lines, form.focus, footer)
+}
@@ -0,0 +1,159 @@
+package frontend
+
+import (
+	"fmt"
+	"hypermark/frontend/styles"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var matchStyle = lipgloss.NewStyle().
+	Bold(true).
+	Underline(true).
+	Foreground(lipgloss.Color(styles.OrangeRed))
+
+func (state *bytemarksManager) query() string {
+	return strings.TrimSpace(state.filter.Value())
+}
+
+// Whether the bytemark at i is shown, ie: it matches the filter.
+func (state *bytemarksManager) visible(i int) bool {
+	if state.query() == "" {
+		return true
+	}
+	_, ok := state.bytemarks[i].Search(state.query())
+	return ok
+}
+
+// The first bytemark shown after from, going by step, or -1.
+func (state *bytemarksManager) nextVisible(from, step int) int {
+	for i := from + step; i >= 0 && i < len(state.bytemarks); i += step {
+		if state.visible(i) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Keep the cursor on a bytemark that is shown, eg: after the one it was
+// on was deleted or filtered out.
+func (state *bytemarksManager) fixCursor() {
+	if state.cursorIndex >= len(state.bytemarks) {
+		state.cursorIndex = len(state.bytemarks) - 1
+	}
+	if state.cursorIndex < 0 {
+		state.cursorIndex = 0
+	}
+	if len(state.bytemarks) == 0 || state.visible(state.cursorIndex) {
+		return
+	}
+	if i := state.nextVisible(state.cursorIndex, 1); i != -1 {
+		state.cursorIndex = i
+	} else if i := state.nextVisible(state.cursorIndex, -1); i != -1 {
+		state.cursorIndex = i
+	}
+}
+
+func (state *bytemarksManager) startFilter() {
+	if state.filter.Value() == "" {
+		state.filter = textinput.NewModel()
+		state.filter.Prompt = "/"
+		state.filter.CharLimit = 156
+		state.filter.Width = 60
+	}
+	state.filter.Focus()
+	state.filtering = true
+	state.visual = false
+	state.moveMode = false
+}
+
+func (state *bytemarksManager) clearFilter() {
+	state.filter.Reset()
+	state.filter.Blur()
+	state.filtering = false
+}
+
+// Typing the filter. The cursor jumps to the first match as it changes.
+func updateFilter(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	state := &m.bytemarksManager
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "esc":
+			state.clearFilter()
+			return m, nil
+		case "enter":
+			state.filter.Blur()
+			state.filtering = false
+			return m, nil
+		case "up", "down":
+			step := 1
+			if msg.String() == "up" {
+				step = -1
+			}
+			if i := state.nextVisible(state.cursorIndex, step); i != -1 {
+				state.cursorIndex = i
+			}
+			return m, nil
+		}
+	}
+
+	query := state.query()
+	state.filter, cmd = state.filter.Update(msg)
+	if state.query() != query {
+		if i := state.nextVisible(-1, 1); i != -1 {
+			state.cursorIndex = i
+		}
+	}
+	return m, cmd
+}
+
+// text with the runes at positions in the match style.
+func highlight(text string, positions []int) string {
+	if len(positions) == 0 {
+		return text
+	}
+	matched := make(map[int]bool)
+	for _, i := range positions {
+		matched[i] = true
+	}
+	var s string
+	for i, r := range []rune(text) {
+		if matched[i] {
+			s += matchStyle.Render(string(r))
+		} else {
+			s += string(r)
+		}
+	}
+	return s
+}
+
+// The filter being typed, or the one in use and how many it matches.
+func filterView(state bytemarksManager) string {
+	if state.filtering {
+		return state.filter.View() + "\n\n"
+	}
+	if state.query() == "" {
+		return ""
+	}
+	matches := 0
+	for i := range state.bytemarks {
+		if state.visible(i) {
+			matches++
+		}
+	}
+	return fmt.Sprintf("%s %s (%d of %d) | %s\n\n",
+		styles.HRender(styles.Crimson, "filter:"),
+		state.query(),
+		matches,
+		len(state.bytemarks),
+		styles.CommandInfo("Clear", "esc"),
+	)
+}
@@ -16,6 +16,8 @@ var initialModel = model{
 			"View hackernews articles",
 			"Manage bytemarks",
 			"Edit hyperpaths",
+			"Find duplicates",
+			"Tags",
 		},
 	},
 	articleMenu: articleMenu{
@@ -23,15 +25,10 @@ var initialModel = model{
 	},
 }
 
-func ClearScreen() {
-	for i := 0; i < 100; i++ {
-		fmt.Println()
-	}
-}
-
 func SetOutputVars(
 	outputPath *os.File,
 	tail []string,
+	target string,
 	overwriteFile bool,
 	writeToStdout bool,
 	clipboardOut bool,
@@ -40,6 +37,7 @@ func SetOutputVars(
 
 	state.outputPath = outputPath
 	state.tail = tail
+	state.target = target
 	state.writeToStdout = writeToStdout
 	state.clipboardOut = clipboardOut
 }
@@ -49,6 +47,7 @@ func (m *model) syncOutputVars() error {
 
 	outputPath, err := utils.ChooseOutputPath(
 		state.tail,
+		state.target,
 		state.overwriteFile,
 		state.writeToStdout,
 		state.clipboardOut,
@@ -62,7 +61,7 @@ func (m *model) initializeArticles() {
 }
 
 func (m *model) loadHyperpaths() {
-	hp, err := utils.GetAllHyperpaths()
+	hp, err := utils.GetHyperpaths()
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -105,10 +104,15 @@ func (m *model) Wipe() {
 }
 
 func (m model) Init() tea.Cmd {
+	unblockInput()
 	return nil
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.WindowSizeMsg); ok {
+		return resize(m, msg), nil
+	}
+
 	switch m.currentView {
 	case startView:
 		return updateStartMenu(m, msg)
@@ -128,6 +132,8 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return updateSentConfirmation(m, msg)
 	case saveChangesView:
 		return updateSaveChanges(m, msg)
+	case saveConflictView:
+		return updateSaveConflict(m, msg)
 	case badURLView:
 		return updateBadURL(m, msg)
 	case hyperpathsView:
@@ -136,12 +142,26 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return updateEditHyperpath(m, msg)
 	case addHPView:
 		return updateEditHyperpath(m, msg)
+	case renameHPView:
+		return updateRenameHyperpath(m, msg)
 	case deleteHyperpathView:
 		return updateDeleteHyperpath(m, msg)
 	case createFileView:
 		return updateCreateFile(m, msg)
 	case invalidFilepathView:
 		return updateInvalidFilepath(m, msg)
+	case dedupeView:
+		return updateDedupe(m, msg)
+	case editTagsView:
+		return updateEditTags(m, msg)
+	case tagCloudView:
+		return updateTagCloud(m, msg)
+	case leaveManagerView:
+		return updateLeaveManager(m, msg)
+	case changesView:
+		return updateChanges(m, msg)
+	case editBytemarkView:
+		return updateEditBytemark(m, msg)
 	}
 	return updateStartMenu(m, msg)
 }
@@ -166,6 +186,8 @@ func (m model) View() string {
 		return promptMenuView(m)
 	case saveChangesView:
 		return promptMenuView(m)
+	case saveConflictView:
+		return promptMenuView(m)
 	case badURLView:
 		return promptMenuView(m)
 	case hyperpathsView:
@@ -174,20 +196,74 @@ func (m model) View() string {
 		return promptAndTextInputView(m)
 	case addHPView:
 		return promptAndTextInputView(m)
+	case renameHPView:
+		return promptAndTextInputView(m)
 	case deleteHyperpathView:
 		return promptMenuView(m)
 	case createFileView:
 		return promptMenuView(m)
 	case invalidFilepathView:
 		return promptMenuView(m)
+	case dedupeView:
+		return dedupeMenuView(m)
+	case editTagsView:
+		return promptAndTextInputView(m)
+	case tagCloudView:
+		return tagCloudMenuView(m)
+	case leaveManagerView:
+		return promptMenuView(m)
+	case changesView:
+		return changesMenuView(m)
+	case editBytemarkView:
+		return editBytemarkMenuView(m)
 	}
 	return startMenuView(m)
 }
 
+// What utils.Warn reported since it was last shown.
+var warnings []string
+
 func Start() {
-	p := tea.NewProgram(initialModel)
-	if err := p.Start(); err != nil {
-		fmt.Println("error: %v", err)
-		os.Exit(1)
+	utils.Warn = func(message string) {
+		warnings = append(warnings, message)
 	}
+	initialModel.loadHyperpaths()
+	m := initialModel
+	for {
+		// The alternate screen is left as it was found on exit.
+		options := []tea.ProgramOption{tea.WithAltScreen()}
+		if tty := openInput(); tty != nil {
+			options = append(options, tea.WithInput(tty))
+		}
+		p := tea.NewProgram(m, options...)
+		err := p.Start()
+		closeInput()
+		if err != nil {
+			fmt.Printf("error: %v\n", err)
+			os.Exit(1)
+		}
+		// The program is stopped while the editor or browser has the
+		// terminal, and started again where it left off.
+		if suspended == nil {
+			return
+		}
+		m = *suspended
+		suspended = nil
+		resume(&m)
+	}
+}
+
+// The model to start again once the terminal is given back, and what to
+// run with the terminal before it is, see suspend.
+var (
+	suspended *model
+	resume    func(m *model)
+)
+
+// Stop the program so that run can have the terminal, eg: to run $EDITOR,
+// and start it again as m once run returns.
+func (m model) suspend(run func(m *model)) (tea.Model, tea.Cmd) {
+	suspended = &m
+	resume = run
+	return m, tea.Quit
 }
@@ -0,0 +1,66 @@
+package frontend
+
+import "hypermark/utils"
+
+// How many edits can be undone.
+const maxUndo = 100
+
+func (state *bytemarksManager) snapshot() edit {
+	bytemarks := make([]utils.Bytemark, len(state.bytemarks))
+	copy(bytemarks, state.bytemarks)
+	return edit{bytemarks: bytemarks, cursorIndex: state.cursorIndex}
+}
+
+func (state *bytemarksManager) restore(e edit) {
+	state.bytemarks = e.bytemarks
+	state.cursorIndex = e.cursorIndex
+	if state.cursorIndex >= len(state.bytemarks) {
+		state.cursorIndex = len(state.bytemarks) - 1
+	}
+	if state.cursorIndex < 0 {
+		state.cursorIndex = 0
+	}
+	state.clearSelection()
+}
+
+// Call before changing the bytemarks, so that the change can be undone.
+func (state *bytemarksManager) record() {
+	state.push(state.snapshot())
+}
+
+// Make e the edit that undo goes back to, eg: a snapshot taken before a
+// change that might not have happened.
+func (state *bytemarksManager) push(e edit) {
+	state.undoStack = append(state.undoStack, e)
+	if len(state.undoStack) > maxUndo {
+		state.undoStack = state.undoStack[1:]
+	}
+	state.redoStack = nil
+}
+
+func (state *bytemarksManager) undo() {
+	if len(state.undoStack) == 0 {
+		return
+	}
+	last := state.undoStack[len(state.undoStack)-1]
+	state.undoStack = state.undoStack[:len(state.undoStack)-1]
+	state.redoStack = append(state.redoStack, state.snapshot())
+	state.restore(last)
+}
+
+func (state *bytemarksManager) redo() {
+	if len(state.redoStack) == 0 {
+		return
+	}
+	last := state.redoStack[len(state.redoStack)-1]
+	state.redoStack = state.redoStack[:len(state.redoStack)-1]
+	state.undoStack = append(state.undoStack, state.snapshot())
+	state.restore(last)
+}
+
+// Edits cannot be undone once the bytemarks are loaded from disk again;
+// saves are undone with hypermark undo instead.
+func (state *bytemarksManager) clearHistory() {
+	state.undoStack = nil
+	state.redoStack = nil
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"log"
 	"strings"
-	"strconv"
 	"hypermark/frontend/styles"
 	"hypermark/frontend/templates"
 	"hypermark/utils"
@@ -32,12 +31,13 @@ func updateHyperpathsMenu(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 					state.cursorIndex++
 				}
 			case "e":
+				if len(state.hyperpaths) == 0 { break }
 				selectedHP := state.hyperpaths[state.cursorIndex]
-				placeholder := selectedHP
+				placeholder := selectedHP.Path
 				prompt := fmt.Sprintf(
 					"%s %s",
 					styles.HRender(styles.Crimson, "Editing"),
-					styles.MakeHyperpathString(state.cursorIndex),
+					styles.MakeHyperpathString(selectedHP.Name),
 				)
 
 				submit := styles.CommandInfo("Submit", "enter")
@@ -47,22 +47,36 @@ func updateHyperpathsMenu(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 				state.editHyperpath.index = state.cursorIndex
 				m.initPromptAndTextInput(placeholder, prompt, footer)
 				m.currentView = editHPView
+			case "r":
+				if len(state.hyperpaths) == 0 { break }
+				selectedHP := state.hyperpaths[state.cursorIndex]
+				prompt := fmt.Sprintf(
+					"%s %s",
+					styles.HRender(styles.Crimson, "Renaming"),
+					styles.MakeHyperpathString(selectedHP.Name),
+				)
+
+				submit := styles.CommandInfo("Submit", "enter")
+				back := styles.CommandInfo("Go back", "esc")
+				footer := fmt.Sprintf("%s | %s", submit, back)
+
+				state.editHyperpath.index = state.cursorIndex
+				m.initPromptAndTextInput(selectedHP.Name, prompt, footer)
+				m.currentView = renameHPView
 			case "d":
-				if len(state.hyperpaths) == 1 { break }
+				if len(state.hyperpaths) <= 1 { break }
 				m.promptMenu.prompt = fmt.Sprintf("Delete '%s'?",
-					state.hyperpaths[state.cursorIndex],
+					state.hyperpaths[state.cursorIndex].Name,
 				)
 				m.promptMenu.options = []string{"Yes", "Cancel"}
 				m.currentView = deleteHyperpathView
 			case "m":
 				state.moveMode = true
 			case "n":
-				placeholder := fmt.Sprintf(
-					"hyperpath[%d]", len(state.hyperpaths),
-				)
-				prompt := fmt.Sprintf("%s %s",
+				placeholder := "path/to/file.md"
+				prompt := fmt.Sprintf("%s a new %s",
 					styles.HRender(styles.Crimson, "Creating"),
-					styles.MakeHyperpathString(len(state.hyperpaths)),
+					styles.HRender(styles.AquaMenthe, "hyperpath"),
 				)
 
 				submit := styles.CommandInfo("Submit", "enter")
@@ -87,7 +101,7 @@ func updateHyperpathsMenu(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 				state.moveMode = false
 			case "up", "k":
 				if state.cursorIndex > 0 {
-					state.hyperpaths = utils.SwapElements(
+					state.hyperpaths = utils.SwapHyperpaths(
 						state.hyperpaths,
 						state.cursorIndex - 1,
 						state.cursorIndex,
@@ -96,7 +110,7 @@ func updateHyperpathsMenu(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			case "down", "j":
 				if state.cursorIndex < len(state.hyperpaths)-1 {
-					state.hyperpaths = utils.SwapElements(
+					state.hyperpaths = utils.SwapHyperpaths(
 						state.hyperpaths,
 						state.cursorIndex + 1,
 						state.cursorIndex,
@@ -113,7 +127,6 @@ func updateHyperpathsMenu(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 func hyperpathsMenuView(m model) string {
 	state := m.hyperpathsMenu
 
-	var s string
 	var del string
 	var move string
 	if len(state.hyperpaths) > 1 && !state.moveMode {
@@ -125,16 +138,23 @@ func hyperpathsMenuView(m model) string {
 		move = fmt.Sprintf(" | %s", styles.CommandInfo("Drop", "m"))
 	}
 
-	s += fmt.Sprintf("\n%s: %s%s%s\n\n",
-		styles.MakeHyperpathString(state.cursorIndex),
+	var selected string
+	if len(state.hyperpaths) > 0 {
+		selected = state.hyperpaths[state.cursorIndex].Name
+	}
+	header := fmt.Sprintf("\n%s: %s | %s%s%s\n\n",
+		styles.MakeHyperpathString(selected),
 		styles.CommandInfo("Edit", "e"),
+		styles.CommandInfo("Rename", "r"),
 		del,
 		move,
 	)
 
-	for i, hyperpath := range state.hyperpaths {
+	lines := make([]string, 0, len(state.hyperpaths))
+	for i, hp := range state.hyperpaths {
 		cursor := ""
-		num := strconv.Itoa(i)
+		num := hp.Name
+		hyperpath := hp.Path
 		colon := ":"
 		if state.cursorIndex == i {
 			cursor = templates.Cursor()
@@ -146,14 +166,14 @@ func hyperpathsMenuView(m model) string {
 			num = styles.HRender(styles.Crimson, num)
 			colon = styles.HRender(styles.OrangeRed, colon)
 		}
-		s += fmt.Sprintf("%s%s%s %s\n", cursor, num, colon, hyperpath)
+		lines = append(lines, fmt.Sprintf("%s%s%s %s", cursor, num, colon, hyperpath))
 	}
 
-	s += fmt.Sprintf("\n%s\n%s\n",
+	footer := fmt.Sprintf("\n%s\n%s\n",
 		styles.CommandInfo("Add new hyperpath", "n"),
 		styles.CommandInfo("Go back", "esc"),
 	)
-	return s
+	return listView(m, header, lines, state.cursorIndex, footer)
 }
 
 func updateEditHyperpath(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -205,6 +225,45 @@ func updateEditHyperpath(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+func updateRenameHyperpath(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	stateA := &m.promptAndTextInput
+	stateB := &m.hyperpathsMenu.editHyperpath
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "esc":
+			m.currentView = hyperpathsView
+			return m, nil
+		case "enter":
+			name := strings.TrimSpace(stateA.textInput.Value())
+			if err := utils.RenameHyperpath(stateB.index, name); err != nil {
+				// Stay on the prompt and show what was wrong.
+				submit := styles.CommandInfo("Submit", "enter")
+				back := styles.CommandInfo("Go back", "esc")
+				stateA.footer = fmt.Sprintf("%s\n\n%s | %s",
+					styles.HRender(styles.Crimson, err.Error()),
+					submit,
+					back,
+				)
+				return m, nil
+			}
+			if err := m.syncOutputVars(); err != nil {
+				log.Fatal(err)
+			}
+			m.loadHyperpaths()
+			m.currentView = hyperpathsView
+			return m, nil
+		}
+	}
+
+	stateA.textInput, cmd = stateA.textInput.Update(msg)
+	return m, cmd
+}
+
 func updateCreateFile(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 	state := &m.promptMenu
 	index := m.hyperpathsMenu.editHyperpath.index
@@ -300,7 +359,7 @@ func updateDeleteHyperpath(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		case "enter", "esc":
 			if stateB.cursorIndex == 0 {
-				stateA.hyperpaths = utils.DeleteElement(
+				stateA.hyperpaths = utils.DeleteHyperpath(
 					stateA.hyperpaths,
 					stateA.cursorIndex,
 				)
@@ -0,0 +1,44 @@
+//go:build !windows
+// +build !windows
+
+package frontend
+
+import (
+	"os"
+	"syscall"
+)
+
+// The terminal the TUI reads keys from, see openInput.
+var (
+	input   *os.File
+	inputFd int
+)
+
+// A terminal of the TUI's own to read keys from, or nil to read from
+// stdin. bubbletea reads keys in a goroutine that is still waiting for one
+// when the program quits, and would take the first key meant for the
+// editor; closing the terminal ends that read.
+func openInput() *os.File {
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return nil
+	}
+	input = tty
+	inputFd = int(tty.Fd())
+	return tty
+}
+
+// bubbletea puts the terminal in blocking mode, in which a read cannot be
+// ended by closing it. Called from Init, before bubbletea reads any keys.
+func unblockInput() {
+	if input != nil {
+		syscall.SetNonblock(inputFd, true)
+	}
+}
+
+func closeInput() {
+	if input != nil {
+		input.Close()
+		input = nil
+	}
+}
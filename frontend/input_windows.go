@@ -0,0 +1,15 @@
+//go:build windows
+// +build windows
+
+package frontend
+
+import "os"
+
+// On Windows bubbletea reads keys from stdin, see input_unix.go.
+func openInput() *os.File {
+	return nil
+}
+
+func unblockInput() {}
+
+func closeInput() {}
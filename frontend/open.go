@@ -0,0 +1,50 @@
+package frontend
+
+import (
+	"fmt"
+	"hypermark/utils"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Open the bytemarks at targets in the browser, or their comments links.
+// Marking them in-progress, if the config asks for it, can be undone.
+func (m *model) openBytemarks(targets []int, comments bool) {
+	state := &m.bytemarksManager
+
+	before := state.snapshot()
+	marked := false
+	failed := make([]string, 0)
+	for _, i := range targets {
+		ok, err := state.bytemarks[i].Open(comments)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", state.bytemarks[i].Title, err))
+		}
+		marked = marked || ok
+	}
+	if marked {
+		state.push(before)
+	}
+	if len(failed) != 0 {
+		m.showMessage("Could not open:\n" + strings.Join(failed, "\n"))
+	}
+}
+
+// Call open, which opens links in the browser, with the program stopped
+// if the browser runs in the terminal, see utils.BrowserInTerminal.
+func (m model) withBrowser(open func(m *model)) (tea.Model, tea.Cmd) {
+	if utils.BrowserInTerminal() {
+		return m.suspend(open)
+	}
+	open(&m)
+	return m, nil
+}
+
+// Open an article of the HN menu, returning what went wrong if anything.
+func openArticle(article *utils.Bytemark, comments bool) string {
+	if _, err := article.Open(comments); err != nil {
+		return fmt.Sprintf("Could not open %s: %v", article.Title, err)
+	}
+	return ""
+}
@@ -0,0 +1,84 @@
+package frontend
+
+import "fmt"
+
+// Whether the bytemark at i is selected, either with space or by being in
+// the visual range.
+func (state *bytemarksManager) isSelected(i int) bool {
+	if !state.visible(i) {
+		return false
+	}
+	if _, ok := state.selected[i]; ok {
+		return true
+	}
+	if !state.visual {
+		return false
+	}
+	start, end := state.visualStart, state.cursorIndex
+	if start > end {
+		start, end = end, start
+	}
+	return i >= start && i <= end
+}
+
+func (state *bytemarksManager) toggleSelected(i int) {
+	if state.selected == nil {
+		state.selected = make(map[int]struct{})
+	}
+	if _, ok := state.selected[i]; ok {
+		delete(state.selected, i)
+	} else {
+		state.selected[i] = struct{}{}
+	}
+}
+
+// Start a visual range at the cursor, or add the range to the selection
+// when one was started.
+func (state *bytemarksManager) toggleVisual() {
+	if !state.visual {
+		state.visual = true
+		state.visualStart = state.cursorIndex
+		return
+	}
+	if state.selected == nil {
+		state.selected = make(map[int]struct{})
+	}
+	for i := range state.bytemarks {
+		if state.isSelected(i) {
+			state.selected[i] = struct{}{}
+		}
+	}
+	state.visual = false
+}
+
+func (state *bytemarksManager) clearSelection() {
+	state.selected = make(map[int]struct{})
+	state.visual = false
+}
+
+// The bytemarks an action applies to, in order: the selected ones that
+// are shown, or the one under the cursor when none are. None if the
+// filter hides them all.
+func (state *bytemarksManager) targets() []int {
+	if len(state.bytemarks) == 0 {
+		return nil
+	}
+	targets := make([]int, 0)
+	for i := range state.bytemarks {
+		if state.isSelected(i) {
+			targets = append(targets, i)
+		}
+	}
+	if len(targets) == 0 && state.visible(state.cursorIndex) {
+		return []int{state.cursorIndex}
+	}
+	return targets
+}
+
+// eg: "'Title'" for one bytemark, "3 bytemarks" for more.
+func (state *bytemarksManager) targetsString(targets []int) string {
+	if len(targets) == 1 {
+		return "'" + state.bytemarks[targets[0]].Title + "'"
+	}
+	return fmt.Sprintf("%d bytemarks", len(targets))
+}
@@ -24,7 +24,6 @@ func updateStartMenu(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 				state.cursorIndex++
 			}
 		case "enter":
-			ClearScreen()
 			switch state.cursorIndex {
 			case 0:
 				m.initializeArticles()
@@ -35,6 +34,12 @@ func updateStartMenu(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
 			case 2:
 				m.loadHyperpaths()
 				m.currentView = hyperpathsView
+			case 3:
+				m.findDuplicates()
+				m.currentView = dedupeView
+			case 4:
+				m.countTags()
+				m.currentView = tagCloudView
 			}
 		}
 	}
@@ -52,7 +57,11 @@ func startMenuView(m model) string {
 
 	//s := "\nhypermark\n\n"
 	s := styles.TitleStyle.Render("hypermark")
-	s += fmt.Sprintf("\n\n%s: %s\n\n", styles.MakeHyperpathString(0), outstr)
+	target := m.outputVars.target
+	if target == "" && len(m.hyperpathsMenu.hyperpaths) > 0 {
+		target = m.hyperpathsMenu.hyperpaths[0].Name
+	}
+	s += fmt.Sprintf("\n\n%s: %s\n\n", styles.MakeHyperpathString(target), outstr)
 	for i, choice := range state.choices {
 		if i == state.cursorIndex {
 			s += templates.Cursor()
@@ -3,6 +3,7 @@ package frontend
 
 import (
 	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	"hypermark/utils"
 	"os"
 )
@@ -19,13 +20,21 @@ const (
 	sendBytemarkView
 	sentConfirmationView
 	saveChangesView
+	saveConflictView
 	badURLView
 	hyperpathsView
 	editHPView
 	addHPView
+	renameHPView
 	deleteHyperpathView
 	createFileView
 	invalidFilepathView
+	dedupeView
+	editTagsView
+	tagCloudView
+	leaveManagerView
+	changesView
+	editBytemarkView
 )
 
 // Generic prompt and text input
@@ -42,18 +51,45 @@ type editHyperpath struct {
 }
 
 type hyperpathsMenu struct {
-	hyperpaths    []string
+	hyperpaths    []utils.Hyperpath
 	editHyperpath editHyperpath
 	moveMode      bool
 	cursorIndex   int
 }
 
 type bytemarksManager struct {
+	collection      *utils.Collection
 	bytemarks       []utils.Bytemark
 	moveMode        bool
 	cursorIndex     int
-	hyperpath       string
-	otherHyperpaths []string
+	hyperpath       utils.Hyperpath
+	otherHyperpaths []utils.Hyperpath
+
+	selected    map[int]struct{}
+	visual      bool // Selecting from visualStart to the cursor.
+	visualStart int
+	sendMove    bool // Whether the send prompt moves or copies.
+
+	undoStack []edit
+	redoStack []edit
+
+	filter    textinput.Model // Only bytemarks that match are shown.
+	filtering bool            // Whether the filter is being typed.
+
+	saved    []utils.Bytemark // As they were loaded, to tell if they changed.
+	quitting bool             // Whether leaving the manager quits hypermark.
+	changes  []utils.FileDiff
+
+	changesViewport viewport.Model
+
+	hideDetail bool                         // Whether the detail pane is hidden.
+	pages      map[string]*utils.CachedPage // Read from the cache, by URL.
+}
+
+// The bytemarks as they were before an edit, see record.
+type edit struct {
+	bytemarks   []utils.Bytemark
+	cursorIndex int
 }
 
 // Generic prompt menu
@@ -67,7 +103,18 @@ type articleMenu struct {
 	articles    []utils.Bytemark
 	selected    map[int]struct{}
 	cursorIndex int
-	pageIndex   int
+	message     string // Shown below the articles until the next key.
+}
+
+type dedupeMenu struct {
+	groups      []utils.DuplicateGroup
+	selected    map[int]struct{} // Groups to merge.
+	cursorIndex int
+	footer      string
+}
+
+type tagCloud struct {
+	counts []utils.TagCount
 }
 
 type startMenu struct {
@@ -77,6 +124,7 @@ type startMenu struct {
 
 type outputVars struct {
 	tail          []string
+	target        string // Name of the hyperpath to write to.
 	overwriteFile bool
 	writeToStdout bool
 	clipboardOut  bool
@@ -86,6 +134,10 @@ type outputVars struct {
 type model struct {
 	outputVars outputVars
 
+	// Size of the terminal, 0 until it is known.
+	width  int
+	height int
+
 	currentView        ViewType // Use this to choose which view to show.
 	startMenu          startMenu
 	articleMenu        articleMenu
@@ -93,4 +145,17 @@ type model struct {
 	hyperpathsMenu     hyperpathsMenu
 	bytemarksManager   bytemarksManager
 	promptAndTextInput promptAndTextInput
+	dedupeMenu         dedupeMenu
+	tagCloud           tagCloud
+	editForm           editForm
+}
+
+// The fields of a bytemark being edited in the manager.
+type editForm struct {
+	index  int                  // Of the bytemark in the manager.
+	fields []promptAndTextInput // Title, URL, tags and notes, then one per row.
+	focus  int
+	err    string
+
+	editing utils.Bytemark // What is given to $EDITOR.
 }
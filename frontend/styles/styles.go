@@ -1,7 +1,6 @@
 package styles
 
 import (
-	"strconv"
 	"fmt"
 	"hypermark/utils"
 	"github.com/charmbracelet/lipgloss"
@@ -90,12 +89,13 @@ func HRender(color, message string) string {
 	return style.Render(message)
 }
 
-func MakeHyperpathString(num int) string {
+// eg: hyperpath[reading]
+func MakeHyperpathString(name string) string {
 	numColor := Crimson
 	hpColor := AquaMenthe
 	bracketColor := OrangeRed
 
-	numStr := HRender(numColor, strconv.Itoa(num))
+	numStr := HRender(numColor, name)
 	hp := HRender(hpColor, "hyperpath")
 
 	lBracket := HRender(bracketColor, "[")
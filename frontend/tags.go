@@ -0,0 +1,141 @@
+package frontend
+
+import (
+	"fmt"
+	"hypermark/frontend/styles"
+	"hypermark/utils"
+	"log"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// eg: " #go #tui", after the title of a bytemark.
+func tagsString(bytemark utils.Bytemark) string {
+	if len(bytemark.Tags) == 0 {
+		return ""
+	}
+	return " " + styles.HRender(styles.AquaMenthe, "#"+strings.Join(bytemark.Tags, " #"))
+}
+
+func (m *model) initTagsPrompt(targets []int) {
+	state := &m.bytemarksManager
+
+	// The tags the bytemarks all have.
+	common := state.bytemarks[targets[0]].Tags
+	for _, i := range targets[1:] {
+		shared := make([]string, 0, len(common))
+		for _, tag := range common {
+			if state.bytemarks[i].HasTag(tag) {
+				shared = append(shared, tag)
+			}
+		}
+		common = shared
+	}
+	current := "none"
+	if len(common) != 0 {
+		current = strings.Join(common, ", ")
+	}
+	prompt := fmt.Sprintf("%s of %s: %s\n%s",
+		styles.HRender(styles.Crimson, "Tags"),
+		state.targetsString(targets),
+		styles.HRender(styles.AquaMenthe, current),
+		"Tags to add, separated by commas. Start a tag with - to remove it.",
+	)
+	submit := styles.CommandInfo("Submit", "enter")
+	back := styles.CommandInfo("Go back", "esc")
+	footer := fmt.Sprintf("%s | %s", submit, back)
+	m.initPromptAndTextInput("go, -read", prompt, footer)
+}
+
+func updateEditTags(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	stateA := &m.promptAndTextInput
+	stateB := &m.bytemarksManager
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "esc":
+			m.currentView = byteManagerView
+			return m, nil
+		case "enter":
+			targets := stateB.targets()
+			if len(targets) != 0 {
+				stateB.record()
+			}
+			for _, i := range targets {
+				bytemark := &stateB.bytemarks[i]
+				bytemark.EditTags(stateA.textInput.Value())
+				bytemark.Touch()
+			}
+			stateB.clearSelection()
+			m.currentView = byteManagerView
+			return m, nil
+		}
+	}
+
+	stateA.textInput, cmd = stateA.textInput.Update(msg)
+	return m, cmd
+}
+
+// Count the tags of every hyperpath.
+func (m *model) countTags() {
+	collections, err := utils.LoadAllCollections()
+	if err != nil {
+		log.Fatal(err)
+	}
+	m.tagCloud.counts = utils.CountTags(utils.AllBytemarks(collections))
+}
+
+func updateTagCloud(m model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "q":
+			return m, tea.Quit
+		case "esc", "enter":
+			m.currentView = startView
+		}
+	}
+	return m, nil
+}
+
+// The tags of every hyperpath, the most used ones in brighter colors.
+func tagCloudMenuView(m model) string {
+	counts := m.tagCloud.counts
+
+	s := styles.HeaderStyle.Render("Tags")
+	s += "\n\n"
+	if len(counts) == 0 {
+		s += "No tags yet. Add some with # in the bytemarks manager.\n"
+	}
+
+	// Wrapped to the terminal when it is narrower than the usual 60.
+	wrap := 60
+	if m.width > 0 && m.width < wrap {
+		wrap = m.width
+	}
+	lineLength := 0
+	for i, count := range counts {
+		color := styles.PalestBlue
+		switch {
+		case i < len(counts)/10+1:
+			color = styles.Crimson
+		case i < len(counts)/3+1:
+			color = styles.OrangeRed
+		}
+		word := fmt.Sprintf("%s (%d)", count.Tag, count.Count)
+		if lineLength > 0 && lineLength+len(word) > wrap {
+			s += "\n"
+			lineLength = 0
+		}
+		s += styles.HRender(color, word) + "  "
+		lineLength += len(word) + 2
+	}
+
+	s += fmt.Sprintf("\n\n%s\n", styles.CommandInfo("Go back", "esc"))
+	return s
+}
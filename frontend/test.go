@@ -38,7 +38,7 @@ func (m testModel) View() string {
 func Test() {
 	p := tea.NewProgram(testModel{})
 	if err := p.Start(); err != nil {
-		fmt.Println("error: %v", err)
+		fmt.Printf("error: %v\n", err)
 		os.Exit(1)
 	}
 }
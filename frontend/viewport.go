@@ -0,0 +1,85 @@
+package frontend
+
+import (
+	"fmt"
+	"hypermark/frontend/styles"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Lines a list keeps for the "more" markers above and below it.
+const scrollMarkers = 2
+
+func resize(m model, msg tea.WindowSizeMsg) model {
+	m.width = msg.Width
+	m.height = msg.Height
+	m.bytemarksManager.changesViewport.Width = msg.Width
+	m.bytemarksManager.changesViewport.Height = changesHeight(msg.Height)
+	return m
+}
+
+func countLines(s string) int {
+	if s == "" {
+		return 0
+	}
+	return strings.Count(strings.TrimSuffix(s, "\n"), "\n") + 1
+}
+
+// The header, the lines of a list and the footer, with the list cut down
+// to the lines that fit in the terminal.
+func listView(m model, header string, lines []string, cursor int, footer string) string {
+	room := m.height - countLines(header) - countLines(footer)
+	return header + scrollLines(m, lines, cursor, room) + footer
+}
+
+// The lines of a list cut down to the width of the terminal and to height
+// lines, including the "more" markers. The list is scrolled so that the
+// line at cursor is in the middle where it can be.
+func scrollLines(m model, lines []string, cursor int, height int) string {
+	if m.width > 0 {
+		for i, line := range lines {
+			if lipgloss.Width(line) > m.width {
+				lines[i] = lipgloss.NewStyle().MaxWidth(m.width).Render(line)
+			}
+		}
+	}
+
+	room := height - scrollMarkers
+	if m.height == 0 || len(lines) <= height {
+		return joinLines(lines)
+	}
+	if room < 1 {
+		room = 1
+	}
+
+	top := cursor - room/2
+	if top > len(lines)-room {
+		top = len(lines) - room
+	}
+	if top < 0 {
+		top = 0
+	}
+	bottom := top + room
+
+	s := moreMarker("↑", top)
+	s += joinLines(lines[top:bottom])
+	s += moreMarker("↓", len(lines)-bottom)
+	return s
+}
+
+func joinLines(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// eg: "↓ 12 more", or a blank line when there are none.
+func moreMarker(arrow string, n int) string {
+	if n == 0 {
+		return "\n"
+	}
+	return styles.HRender(styles.PalestBlue, fmt.Sprintf("%s %d more", arrow, n)) + "\n"
+}
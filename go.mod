@@ -3,20 +3,21 @@ module hypermark
 go 1.16
 
 require (
-	github.com/PuerkitoBio/goquery v1.7.1 // indirect
+	github.com/BurntSushi/toml v1.3.2
+	github.com/PuerkitoBio/goquery v1.7.1
 	github.com/antchfx/htmlquery v1.2.3 // indirect
 	github.com/antchfx/xmlquery v1.3.6 // indirect
 	github.com/antchfx/xpath v1.2.0 // indirect
-	github.com/atotto/clipboard v0.1.4 // indirect
-	github.com/charmbracelet/bubbles v0.8.0 // indirect
-	github.com/charmbracelet/bubbletea v0.14.1 // indirect
-	github.com/charmbracelet/lipgloss v0.3.0 // indirect
+	github.com/atotto/clipboard v0.1.4
+	github.com/charmbracelet/bubbles v0.8.0
+	github.com/charmbracelet/bubbletea v0.14.1
+	github.com/charmbracelet/lipgloss v0.3.0
 	github.com/gobwas/glob v0.2.3 // indirect
-	github.com/gocolly/colly v1.2.0 // indirect
+	github.com/gocolly/colly v1.2.0
 	github.com/golang/groupcache v0.0.0-20210331224755-41bb18bfe9da // indirect
 	github.com/golang/protobuf v1.5.2 // indirect
 	github.com/google/goterm v0.0.0-20190703233501-fc88cf888a3f // indirect
-	github.com/jawher/mow.cli v1.2.0 // indirect
+	github.com/jawher/mow.cli v1.2.0
 	github.com/kennygrant/sanitize v1.2.4 // indirect
 	github.com/pkg/term v0.0.0-20200520122047-c3ffed290a03 // indirect
 	github.com/saintfish/chardet v0.0.0-20120816061221-3af4cd4741ca // indirect
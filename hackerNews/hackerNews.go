@@ -4,14 +4,29 @@ import (
 	"fmt"
 	"github.com/gocolly/colly"
 	"hypermark/utils"
+	"sync"
+	"time"
 )
 
-const HN_URL = "https://news.ycombinator.com/"
+const (
+	HN_URL = "https://news.ycombinator.com/"
+	SOURCE = "hackernews"
+
+	// For following the redirects of the articles, see
+	// utils.FollowRedirects. The articles are followed at the same time,
+	// each within the timeout and all of them within the deadline.
+	REDIRECT_TIMEOUT  = 10 * time.Second
+	REDIRECT_DEADLINE = 20 * time.Second
+	REDIRECT_WORKERS  = 8
+)
 
 func GetHNInfo(b utils.Bytemark) (title, storyLink, commentLink string) {
 	title = b.Title
 	storyLink = b.RootURL
-	commentLink = b.Rows[0]
+	commentLink = b.CommentsURL
+	if commentLink == "" {
+		commentLink = "No comments."
+	}
 
 	return title, storyLink, commentLink
 }
@@ -37,18 +52,48 @@ func ScrapeHN() []utils.Bytemark {
 	c.OnHTML(".athing + tr", func(e *colly.HTMLElement) {
 		selector := "td.subtext a:nth-child(6)"
 		commentLink := e.ChildAttr(selector, "href")
-		articles[cIndex].Rows = append(articles[cIndex].Rows, commentLink)
+		if commentLink != "" {
+			articles[cIndex].CommentsURL = HN_URL + commentLink
+		}
 		cIndex++
 	})
 
 	c.Visit(HN_URL)
 	for i := 0; i < NUM_OF_ARTICLES; i++ {
 		articles[i].SetDateTimeNow()
-		if len(articles[i].Rows) != 0 {
-			articles[i].Rows[0] = "Comments: " + HN_URL + articles[i].Rows[0]
-		} else {
-			articles[i].Rows = append(articles[i].Rows, "No comments.")
-		}
+		articles[i].Source = SOURCE
 	}
+	canonicalizeArticles(articles)
 	return articles
 }
+
+func canonicalizeArticles(articles []utils.Bytemark) {
+	deadline := time.Now().Add(REDIRECT_DEADLINE)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < REDIRECT_WORKERS; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				canonicalizeArticle(&articles[i], deadline)
+			}
+		}()
+	}
+	for i := range articles {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+func canonicalizeArticle(article *utils.Bytemark, deadline time.Time) {
+	timeout := time.Until(deadline)
+	if timeout > REDIRECT_TIMEOUT {
+		timeout = REDIRECT_TIMEOUT
+	}
+	if timeout <= 0 || article.CanonicalizeURL(timeout) != nil {
+		// Could not follow the redirects, settle for the link itself.
+		article.SetCanonicalURL(article.RootURL)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"hypermark/hackerNews"
+	"hypermark/utils"
+	"os"
+	"strings"
+)
+
+// The number of articles on the front page.
+const hnArticles = 30
+
+func showArticles(articles []utils.Bytemark) {
+	for i := 0; i < hnArticles; i++ {
+		title, sLink, cLink := hackerNews.GetHNInfo(articles[i])
+		fmt.Printf("%d. %s\n%s\n%s\n\n", i+1, title, sLink, cLink)
+	}
+}
+
+func saveArticlesWithKeyword(
+	articles []utils.Bytemark,
+	keyword string,
+	outputPath *os.File,
+	clipboardOut bool,
+) error {
+	fmt.Printf("Searching for articles with '%s' in the title.\n", keyword)
+
+	found := make([]utils.Bytemark, 0)
+	for i := 0; i < hnArticles; i++ {
+		if articles[i].TitleContains(keyword) {
+			found = append(found, articles[i])
+		}
+	}
+	writtenTo, _, err := writeBytemarks(outputPath, found, clipboardOut)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d articles found. Writing output to %s.\n",
+		len(found),
+		writtenTo,
+	)
+	return nil
+}
+
+// Ask which articles to save.
+func saveSelectedArticles(
+	articles []utils.Bytemark,
+	outputPath *os.File,
+	clipboardOut bool,
+) error {
+	for i, article := range articles {
+		fmt.Printf("%d %s\n", i+1, article.Title)
+	}
+
+	fmt.Printf("\nArticles to save: (eg: 1 2 3, 1-3)\n")
+	reader := bufio.NewReader(os.Stdin)
+	userInput, err := reader.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	userInput = strings.TrimSuffix(userInput, "\n")
+
+	selections, err := utils.GetUserSelections(userInput)
+	if err != nil {
+		return err
+	}
+
+	selected := make([]utils.Bytemark, 0, len(selections))
+	for _, sel := range selections {
+		selected = append(selected, articles[sel-1])
+	}
+
+	writtenTo, written, err := writeBytemarks(outputPath, selected, clipboardOut)
+	if err != nil {
+		return err
+	}
+	fmt.Printf(
+		"%d articles written to %s.\n",
+		written,
+		writtenTo,
+	)
+	return nil
+}
+
+func saveBytemark(
+	bytemark utils.Bytemark,
+	outputPath *os.File,
+	clipboardOut bool,
+) error {
+	writtenTo, written, err := writeBytemarks(
+		outputPath, []utils.Bytemark{bytemark}, clipboardOut)
+	if err != nil || written == 0 {
+		return err
+	}
+	fmt.Printf("bytemark for %s was written to %s.\n",
+		bytemark.RootURL,
+		writtenTo,
+	)
+	return nil
+}
+
+// Write bytemarks, reporting the ones that were already saved, see
+// utils.Duplicates.
+func writeBytemarks(
+	outputPath *os.File,
+	bytemarks []utils.Bytemark,
+	clipboardOut bool,
+) (writtenTo string, written int, err error) {
+	writtenTo, written, duplicates, err := utils.WriteBytemarks(
+		outputPath, bytemarks, clipboardOut)
+	reportDuplicates(duplicates)
+	return writtenTo, written, err
+}
+
+func reportDuplicates(duplicates []utils.Duplicate) {
+	for _, duplicate := range duplicates {
+		fmt.Fprintf(os.Stderr, "duplicate: %s\n", duplicate)
+	}
+}
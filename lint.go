@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"hypermark/utils"
+	"os"
+)
+
+// Exit codes of the lint command.
+const (
+	lintOK       = 0
+	lintProblems = 1
+	lintFailed   = 2
+)
+
+// Print a diagnostic for every malformed bytemark table in files.
+// Exits non-zero if any were found so it can be used in CI.
+func lint(files []string) int {
+	if len(files) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: hypermark lint <file>...")
+		return lintFailed
+	}
+
+	code := lintOK
+	for _, file := range files {
+		doc, err := utils.LoadDocument(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			code = lintFailed
+			continue
+		}
+		for _, d := range doc.Diagnostics {
+			fmt.Println(d.Error())
+			if code == lintOK {
+				code = lintProblems
+			}
+		}
+	}
+	return code
+}
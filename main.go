@@ -1,7 +1,6 @@
 package main
 
 import (
-	"bufio"
 	"flag"
 	"fmt"
 	"hypermark/frontend"
@@ -10,6 +9,7 @@ import (
 	"hypermark/utils"
 	"log"
 	"os"
+	"strings"
 )
 
 // flags
@@ -21,10 +21,20 @@ var (
 	url          bool
 	clipboardOut bool
 	tui          bool
+	backup       bool
+	configFile   string
+	to           string
+	duplicates   string
 	//tuiTest      bool
 	//test         bool
 )
 
+// Flags that only the deprecated, command-less invocation has.
+var legacyFlags = map[string]bool{
+	"k": true, "o": true, "s": true, "stdout": true, "url": true,
+	"c": true, "tui": true, "bak": true, "to": true,
+}
+
 func init() {
 	// k and s are mutually exclusive.
 	flag.StringVar(&k, "k", "",
@@ -34,11 +44,17 @@ func init() {
 	flag.BoolVar(&s, "s", false, "Show all HN articles and exit.")
 	flag.BoolVar(&stdout, "stdout", false, "Write output to stdout.")
 	flag.BoolVar(&url, "url", false,
-		"Use a URL from the system clipboard.")
+		"Use a URL from the system clipboard, or stdin if the source setting is stdin.")
 	flag.BoolVar(&clipboardOut, "c", false,
 		"Input will be written to the system clipboard.")
 	flag.BoolVar(&tui, "tui", false,
 		"Use TUI.")
+	flag.BoolVar(&backup, "bak", false,
+		"Keep a .bak of files before they are rewritten.")
+	flag.StringVar(&to, "to", "",
+		"Name of the hyperpath to write to instead of the first one.")
+	flag.StringVar(&configFile, "config", "",
+		"Path to the config file (default $XDG_CONFIG_HOME/hypermark/config.toml).")
 	/*
 	flag.BoolVar(&tuiTest, "tuiTest", false,
 		"Run stubs to test TUI styling.")
@@ -47,15 +63,93 @@ func init() {
 	*/
 }
 
+// Use the defaults from the config file for the flags that were not
+// given on the command line.
+func applyConfig(given map[string]bool) error {
+	if configFile != "" {
+		utils.SetConfigPath(configFile)
+	}
+	cfg, err := utils.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	if !given["o"] {
+		o = cfg.Defaults.Overwrite
+	}
+	if !given["c"] {
+		clipboardOut = cfg.Defaults.Clipboard
+	}
+	if !given["bak"] {
+		backup = cfg.Defaults.Backup
+	}
+	utils.KeepBackups = backup
+	utils.JournalSize = cfg.Defaults.Journal
+	utils.MarkOpened = cfg.Defaults.MarkOpened
+	if !given["duplicates"] {
+		duplicates = cfg.Defaults.Duplicates
+	}
+	if duplicates != "" {
+		policy, err := utils.ParseDuplicatePolicy(duplicates)
+		if err != nil {
+			return err
+		}
+		utils.Duplicates = policy
+	}
+	urlMode.URLSource = cfg.Defaults.Source
+	utils.TrackingParams = cfg.URLs.TrackingParams
+	utils.FollowRedirects = cfg.URLs.FollowRedirects
+	return nil
+}
+
+// Whether args are those of the old, command-less interface,
+// eg: hypermark -k rust notes.md or hypermark notes.md
+func usesLegacyFlags(args []string) bool {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			// A command, or the file of the old interface.
+			return !isCommand(arg)
+		}
+		name := strings.SplitN(strings.TrimLeft(arg, "-"), "=", 2)[0]
+		if name == "config" && !strings.Contains(arg, "=") {
+			i++ // Skip the value.
+			continue
+		}
+		if legacyFlags[name] {
+			return true
+		}
+	}
+	return false
+}
+
 func main() {
+	if usesLegacyFlags(os.Args[1:]) {
+		fmt.Fprintln(os.Stderr,
+			"hypermark: running without a command is deprecated, see 'hypermark --help'.")
+		legacyMain()
+		return
+	}
+	newApp().Run(joinDashValues(os.Args))
+}
+
+// The command-less interface, kept for the old flags.
+func legacyMain() {
 	flag.Parse()
+	given := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+	if err := applyConfig(given); err != nil {
+		log.Fatal(err)
+	}
 	var outputPath *os.File
 	var err error
 
 	// outputPath is either a user-provided file or Stdout.
 	// Accommodations made for system clipboard.
 	outputPath, err = utils.ChooseOutputPath(
-		flag.Args(), o, stdout, clipboardOut)
+		flag.Args(), to, o, stdout, clipboardOut)
 	if err != nil {
 		if err.Error() == utils.EARLY_EXIT {
 			return
@@ -68,11 +162,11 @@ func main() {
 		frontend.SetOutputVars(
 			outputPath,
 			flag.Args(),
+			to,
 			o,
 			stdout,
 			clipboardOut,
 		)
-		frontend.ClearScreen()
 		frontend.Start()
 		return
 	}
@@ -94,76 +188,21 @@ func main() {
 		if err != nil {
 			log.Fatal(err)
 		}
-
-		output := bytemark.Table()
-		writtenTo, err := utils.Write(outputPath, output, clipboardOut)
-		if err != nil {
+		if err := saveBytemark(bytemark, outputPath, clipboardOut); err != nil {
 			log.Fatal(err)
 		}
-		fmt.Printf("bytemark for %s was written to %s.\n",
-			bytemark.RootURL,
-			writtenTo,
-		)
 		return
 	}
 
 	articles := hackerNews.ScrapeHN()
 	if s {
-		for i := 0; i < 30; i++ {
-			title, sLink, cLink := hackerNews.GetHNInfo(articles[i])
-			fmt.Printf("%d. %s\n%s\n%s\n\n", i+1, title, sLink, cLink)
-		}
+		showArticles(articles)
 	} else if k != "" {
-		fmt.Printf("Searching for articles with '%s' in the title.\n", k)
-
-		output := ""
-		articlesFound := 0
-		for i := 0; i < 30; i++ {
-			if articles[i].TitleContains(k) {
-				output += articles[i].Table()
-				articlesFound++
-			}
-		}
-		writtenTo, err := utils.Write(outputPath, output, clipboardOut)
-		if err != nil {
-			log.Fatal(err)
-		}
-		fmt.Printf("%d articles found. Writing output to %s.\n",
-			articlesFound,
-			writtenTo,
-		)
+		err = saveArticlesWithKeyword(articles, k, outputPath, clipboardOut)
 	} else {
-		for i, article := range articles {
-			fmt.Printf("%d %s\n", i+1, article.Title)
-		}
-
-		var userInput string
-		fmt.Printf("\nArticles to save: (eg: 1 2 3, 1-3)\n")
-		reader := bufio.NewReader(os.Stdin)
-		userInput, err := reader.ReadString('\n')
-		userInput = userInput[:len(userInput)-1] // remove trailing newline
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		selections, err := utils.GetUserSelections(userInput)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		var output string
-		for _, sel := range selections {
-			output += articles[sel-1].Table()
-		}
-
-		writtenTo, err := utils.Write(outputPath, output, clipboardOut)
-		if err != nil {
-			log.Fatal(err)
-		}
-		fmt.Printf(
-			"%d articles written to %s.\n",
-			len(selections),
-			writtenTo,
-		)
+		err = saveSelectedArticles(articles, outputPath, clipboardOut)
+	}
+	if err != nil {
+		log.Fatal(err)
 	}
 }
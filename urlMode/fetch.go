@@ -0,0 +1,174 @@
+package urlMode
+
+import (
+	"errors"
+	"net/url"
+	"sync"
+	"time"
+
+	"hypermark/utils"
+)
+
+// Defaults of NewFetcher.
+const (
+	DEFAULT_WORKERS    = 8
+	DEFAULT_HOST_DELAY = 500 * time.Millisecond
+	DEFAULT_TIMEOUT    = 15 * time.Second
+	DEFAULT_RETRIES    = 2
+	DEFAULT_BACKOFF    = time.Second
+)
+
+var ErrDeadline = errors.New("Gave up: the time for fetching ran out.")
+
+// The outcome of fetching one URL.
+type Result struct {
+	URL      string
+	Bytemark utils.Bytemark
+	Err      error
+}
+
+// Makes bytemarks for many URLs at once. Requests to the same host are
+// spaced out by HostDelay, and failed requests are retried with an
+// exponential backoff, eg: 1s, 2s, 4s.
+type Fetcher struct {
+	Workers   int
+	HostDelay time.Duration
+	Timeout   time.Duration // Of each request.
+	Deadline  time.Duration // Of the whole Fetch; 0 for none.
+	Retries   int
+	Backoff   time.Duration
+
+	// Makes the bytemark for one URL; createBytemark unless replaced.
+	create func(url string, timeout time.Duration) (utils.Bytemark, error)
+
+	mu    sync.Mutex
+	hosts map[string]time.Time // When the next request to a host may start.
+}
+
+func NewFetcher() *Fetcher {
+	return &Fetcher{
+		Workers:   DEFAULT_WORKERS,
+		HostDelay: DEFAULT_HOST_DELAY,
+		Timeout:   DEFAULT_TIMEOUT,
+		Retries:   DEFAULT_RETRIES,
+		Backoff:   DEFAULT_BACKOFF,
+		create:    createBytemark,
+	}
+}
+
+// Fetch every URL. The results are in the same order as urls.
+func (f *Fetcher) Fetch(urls []string) []Result {
+	results := make([]Result, len(urls))
+	var deadline time.Time
+	if f.Deadline > 0 {
+		deadline = time.Now().Add(f.Deadline)
+	}
+	f.mu.Lock()
+	f.hosts = make(map[string]time.Time)
+	f.mu.Unlock()
+
+	workers := f.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = f.fetch(urls[i], deadline)
+			}
+		}()
+	}
+	for i := range urls {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+func (f *Fetcher) fetch(rawURL string, deadline time.Time) Result {
+	result := Result{URL: rawURL}
+	backoff := f.Backoff
+	for attempt := 0; ; attempt++ {
+		if !sleepUntil(f.reserve(rawURL), deadline) {
+			result.Err = ErrDeadline
+			return result
+		}
+		result.Bytemark, result.Err = f.create(rawURL, f.requestTimeout(deadline))
+		if result.Err == nil || attempt == f.Retries || !retryable(result.Err) {
+			return result
+		}
+		if !sleepUntil(time.Now().Add(backoff), deadline) {
+			return result
+		}
+		backoff *= 2
+	}
+}
+
+// The timeout of a request, cut short by the deadline.
+func (f *Fetcher) requestTimeout(deadline time.Time) time.Duration {
+	timeout := f.Timeout
+	if !deadline.IsZero() {
+		if left := time.Until(deadline); timeout == 0 || left < timeout {
+			timeout = left
+		}
+	}
+	return timeout
+}
+
+// Book the next slot for a request to the host of rawURL and return
+// when it starts.
+func (f *Fetcher) reserve(rawURL string) time.Time {
+	host := rawURL
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		host = u.Host
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	start := time.Now()
+	if next := f.hosts[host]; next.After(start) {
+		start = next
+	}
+	f.hosts[host] = start.Add(f.HostDelay)
+	return start
+}
+
+// Sleep until t. Returns false, without sleeping, if t is past the
+// deadline.
+func sleepUntil(t, deadline time.Time) bool {
+	if !deadline.IsZero() && !t.Before(deadline) {
+		return false
+	}
+	time.Sleep(time.Until(t))
+	return true
+}
+
+// A page that could not be fetched.
+type fetchError struct {
+	url       string
+	status    int  // Of the response, 0 if there was none.
+	requested bool // false if the URL was not even requested.
+	err       error
+}
+
+func (e *fetchError) Error() string {
+	return "Could not make bytemark using URL: " + e.url + ": " + e.err.Error() + "."
+}
+
+// Whether trying again might work: timeouts, refused connections and
+// server errors, but not eg: 404 or a malformed URL.
+func retryable(err error) bool {
+	var fe *fetchError
+	if !errors.As(err, &fe) {
+		return false
+	}
+	if !fe.requested {
+		return false
+	}
+	return fe.status == 0 || fe.status == 429 || fe.status >= 500
+}
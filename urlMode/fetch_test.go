@@ -0,0 +1,204 @@
+package urlMode
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"hypermark/utils"
+)
+
+// A fetcher without delays, whose pages are cached in a temporary
+// directory rather than the user's.
+func testFetcher(t *testing.T) *Fetcher {
+	cache, set := os.LookupEnv("XDG_CACHE_HOME")
+	os.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Cleanup(func() {
+		if set {
+			os.Setenv("XDG_CACHE_HOME", cache)
+		} else {
+			os.Unsetenv("XDG_CACHE_HOME")
+		}
+	})
+
+	f := NewFetcher()
+	f.HostDelay = 0
+	f.Backoff = time.Millisecond
+	return f
+}
+
+func TestFetchOrder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first pages are the slowest, so they finish last.
+		var n int
+		fmt.Sscanf(r.URL.Path, "/%d", &n)
+		time.Sleep(time.Duration(10-n) * 5 * time.Millisecond)
+		fmt.Fprintf(w, "<html><head><title>page %d</title></head></html>", n)
+	}))
+	defer server.Close()
+
+	f := testFetcher(t)
+	f.Workers = 4
+	urls := make([]string, 10)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("%s/%d", server.URL, i)
+	}
+
+	results := f.Fetch(urls)
+	if len(results) != len(urls) {
+		t.Fatalf("got %d results, want %d", len(results), len(urls))
+	}
+	for i, result := range results {
+		if result.Err != nil {
+			t.Fatalf("%s: %v", result.URL, result.Err)
+		}
+		if result.URL != urls[i] {
+			t.Errorf("result %d is for %s, want %s", i, result.URL, urls[i])
+		}
+		if want := fmt.Sprintf("page %d", i); result.Bytemark.Title != want {
+			t.Errorf("result %d has title %q, want %q", i, result.Bytemark.Title, want)
+		}
+	}
+}
+
+func TestFetchHostDelay(t *testing.T) {
+	var mu sync.Mutex
+	starts := make(map[string][]time.Time)
+
+	f := testFetcher(t)
+	f.HostDelay = 50 * time.Millisecond
+	f.create = func(url string, timeout time.Duration) (utils.Bytemark, error) {
+		host := url[:len("http://a")]
+		mu.Lock()
+		starts[host] = append(starts[host], time.Now())
+		mu.Unlock()
+		return utils.Bytemark{RootURL: url}, nil
+	}
+
+	begin := time.Now()
+	f.Fetch([]string{
+		"http://a/1", "http://b/1", "http://a/2",
+		"http://b/2", "http://a/3", "http://b/3",
+	})
+
+	for host, times := range starts {
+		if len(times) != 3 {
+			t.Fatalf("%s was requested %d times, want 3", host, len(times))
+		}
+		if first := times[0].Sub(begin); first >= f.HostDelay {
+			t.Errorf("the first request to %s waited %v", host, first)
+		}
+		for i := 1; i < len(times); i++ {
+			// The sleep may wake up a little early on some platforms.
+			if gap := times[i].Sub(times[i-1]); gap < f.HostDelay-5*time.Millisecond {
+				t.Errorf("requests to %s were %v apart, want at least %v", host, gap, f.HostDelay)
+			}
+		}
+	}
+}
+
+func TestFetchTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(300 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	f := testFetcher(t)
+	f.Timeout = 50 * time.Millisecond
+	f.Retries = 0
+
+	begin := time.Now()
+	results := f.Fetch([]string{server.URL})
+	if results[0].Err == nil {
+		t.Fatal("a request longer than the timeout succeeded")
+	}
+	if took := time.Since(begin); took >= 250*time.Millisecond {
+		t.Errorf("the request took %v with a timeout of %v", took, f.Timeout)
+	}
+}
+
+func TestFetchDeadline(t *testing.T) {
+	f := testFetcher(t)
+	f.HostDelay = time.Second
+	f.Deadline = 100 * time.Millisecond
+	var timeouts []time.Duration
+	f.create = func(url string, timeout time.Duration) (utils.Bytemark, error) {
+		timeouts = append(timeouts, timeout)
+		return utils.Bytemark{RootURL: url}, nil
+	}
+
+	// The second request to the host would start after the deadline.
+	begin := time.Now()
+	results := f.Fetch([]string{"http://a/1", "http://a/2"})
+	if took := time.Since(begin); took >= f.HostDelay {
+		t.Errorf("Fetch took %v with a deadline of %v", took, f.Deadline)
+	}
+	if results[0].Err != nil {
+		t.Errorf("%s: %v", results[0].URL, results[0].Err)
+	}
+	if !errors.Is(results[1].Err, ErrDeadline) {
+		t.Errorf("%s: got error %v, want %v", results[1].URL, results[1].Err, ErrDeadline)
+	}
+	if len(timeouts) != 1 || timeouts[0] > f.Deadline {
+		t.Errorf("got request timeouts %v, want one of at most %v", timeouts, f.Deadline)
+	}
+}
+
+func TestFetchRetries(t *testing.T) {
+	var mu sync.Mutex
+	requests := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		n := requests[r.URL.Path]
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/flaky":
+			if n < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+		case "/busy":
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, "<html><head><title>ok</title></head></html>")
+	}))
+	defer server.Close()
+
+	f := testFetcher(t)
+	f.Retries = 2
+
+	tests := []struct {
+		path     string
+		requests int
+		ok       bool
+	}{
+		{"/flaky", 3, true},
+		{"/busy", 3, false},
+		{"/missing", 1, false},
+	}
+	urls := make([]string, len(tests))
+	for i, test := range tests {
+		urls[i] = server.URL + test.path
+	}
+
+	results := f.Fetch(urls)
+	for i, test := range tests {
+		if ok := results[i].Err == nil; ok != test.ok {
+			t.Errorf("%s: got error %v", test.path, results[i].Err)
+		}
+		if requests[test.path] != test.requests {
+			t.Errorf("%s was requested %d times, want %d", test.path, requests[test.path], test.requests)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package urlMode
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gocolly/colly"
+	"hypermark/utils"
+)
+
+// The first non-empty content of the meta tags named by keys, in order.
+// keys match the name or property attribute, eg: og:title.
+func metaContent(head *goquery.Selection, keys ...string) string {
+	for _, key := range keys {
+		for _, attr := range []string{"name", "property", "itemprop"} {
+			selector := "meta[" + attr + "='" + key + "']"
+			if content := cleanText(head.Find(selector).First().AttrOr("content", "")); content != "" {
+				return content
+			}
+		}
+	}
+	return ""
+}
+
+// Collapse runs of whitespace, so that the text fits on a table row.
+func cleanText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// The absolute URL of the first <link> with a rel in rels.
+func linkHref(e *colly.HTMLElement, rels ...string) string {
+	for _, rel := range rels {
+		href := e.DOM.Find("link[rel~='" + rel + "']").First().AttrOr("href", "")
+		if href = strings.TrimSpace(href); href != "" {
+			return e.Request.AbsoluteURL(href)
+		}
+	}
+	return ""
+}
+
+func absoluteURL(e *colly.HTMLElement, href string) string {
+	if href == "" {
+		return ""
+	}
+	return e.Request.AbsoluteURL(href)
+}
+
+// Fill in the title and page metadata of bytemark from the <html> of a
+// page. The title falls back to og:title, the first <h1> and then the URL.
+func readPage(e *colly.HTMLElement, bytemark *utils.Bytemark) {
+	dom := e.DOM
+	head := dom.Find("head")
+
+	bytemark.Title = cleanText(head.Find("title").First().Text())
+	if bytemark.Title == "" {
+		bytemark.Title = metaContent(head, "og:title", "twitter:title")
+	}
+	if bytemark.Title == "" {
+		bytemark.Title = cleanText(dom.Find("h1").First().Text())
+	}
+
+	bytemark.Page = utils.PageMeta{
+		Description: metaContent(head,
+			"description", "og:description", "twitter:description"),
+		Author: metaContent(head,
+			"author", "article:author", "twitter:creator"),
+		Published: metaContent(head,
+			"article:published_time", "datePublished", "date", "pubdate"),
+		Canonical: linkHref(e, "canonical"),
+		SiteName:  metaContent(head, "og:site_name", "application-name"),
+		Lang:      strings.TrimSpace(dom.AttrOr("lang", "")),
+		Favicon:   linkHref(e, "icon", "apple-touch-icon"),
+		Image:     absoluteURL(e, metaContent(head, "og:image", "twitter:image")),
+	}
+	if bytemark.Page.Canonical == "" {
+		bytemark.Page.Canonical = absoluteURL(e, metaContent(head, "og:url"))
+	}
+}
+
+// About how much of the text of a page is cached, in runes.
+const EXCERPT_LENGTH = 600
+
+// The start of the readable text of a page: its first paragraphs, from the
+// <article> or <main> of the page if it has one.
+func readExcerpt(e *colly.HTMLElement) string {
+	dom := e.DOM
+	content := dom.Find("article").First()
+	if content.Length() == 0 {
+		content = dom.Find("main").First()
+	}
+	if content.Length() == 0 {
+		content = dom.Find("body")
+	}
+
+	var excerpt string
+	content.Find("p").EachWithBreak(func(_ int, p *goquery.Selection) bool {
+		text := cleanText(p.Text())
+		if text == "" {
+			return true
+		}
+		if excerpt != "" {
+			excerpt += "\n\n"
+		}
+		excerpt += text
+		return len([]rune(excerpt)) < EXCERPT_LENGTH
+	})
+	return truncateWords(excerpt, EXCERPT_LENGTH)
+}
+
+// s cut at the last space before max runes, eg: "a long…".
+func truncateWords(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	cut := string(runes[:max])
+	if i := strings.LastIndexAny(cut, " \n"); i > 0 {
+		cut = cut[:i]
+	}
+	return cut + "…"
+}
@@ -2,17 +2,29 @@ package urlMode
 
 import (
 	//"fmt"
+	"bufio"
+	"io"
+	"os"
+	"strings"
 	"github.com/atotto/clipboard"
 	"github.com/gocolly/colly"
 	"hypermark/utils"
 	"fmt"
-	"errors"
+	"time"
 )
 
-func createBytemark(url string) (utils.Bytemark, error) {
-	bytemark := utils.Bytemark{RootURL: url}
-	var retErr error
+const SOURCE = "url"
+
+// Make a bytemark for url, using the title and metadata of the page.
+func CreateBytemark(url string) (utils.Bytemark, error) {
+	return createBytemark(url, DEFAULT_TIMEOUT)
+}
+
+func createBytemark(url string, timeout time.Duration) (utils.Bytemark, error) {
+	bytemark := utils.Bytemark{RootURL: url, Source: SOURCE}
+	var retErr *fetchError
 	c := colly.NewCollector()
+	c.SetRequestTimeout(timeout)
 
 	/*
 	c.OnRequest(func(r *colly.Request) {
@@ -20,26 +32,96 @@ func createBytemark(url string) (utils.Bytemark, error) {
 	})
 	*/
 
-	c.OnHTML("head title", func(e *colly.HTMLElement) {
-		bytemark.Title = e.Text
+	finalURL := url
+	c.OnResponse(func(r *colly.Response) {
+		finalURL = r.Request.URL.String() // After redirects.
+	})
+
+	var excerpt string
+	c.OnHTML("html", func(e *colly.HTMLElement) {
+		readPage(e, &bytemark)
+		excerpt = readExcerpt(e)
 	})
 
 	c.OnError(func(r *colly.Response, err error) {
-		errStr := fmt.Sprintf("Could not make bytemark using URL: %s.", url)
-		retErr = errors.New(errStr)
+		retErr = &fetchError{
+			url:       url,
+			status:    r.StatusCode,
+			requested: true,
+			err:       err,
+		}
 	})
 
-	c.Visit(url)
+	if err := c.Visit(url); err != nil && retErr == nil {
+		retErr = &fetchError{url: url, err: err}
+	}
+	if retErr != nil {
+		return bytemark, retErr
+	}
+	if !utils.FollowRedirects {
+		finalURL = url
+	}
+	if err := bytemark.SetCanonicalURL(finalURL); err != nil {
+		return bytemark, &fetchError{url: url, err: err}
+	}
+	if bytemark.Title == "" {
+		bytemark.Title = bytemark.RootURL
+	}
 
 	bytemark.SetDateTimeNow()
-	return bytemark, retErr
+	cachePage(bytemark, excerpt)
+	return bytemark, nil
+}
+
+// Keep what was read from the page for the detail pane of the TUI. The
+// cache is a convenience, so failing to write it is not an error.
+func cachePage(bytemark utils.Bytemark, excerpt string) {
+	utils.WriteCachedPage(utils.CachedPage{
+		URL:     bytemark.RootURL,
+		Fetched: time.Now().Truncate(time.Second),
+		Title:   bytemark.Title,
+		Page:    bytemark.Page,
+		Excerpt: excerpt,
+	})
+}
+
+// Where BytemarkFromURL reads the URL from, see utils.Defaults.
+var URLSource = utils.ClipboardSource
+
+func readURL() (string, error) {
+	switch URLSource {
+	case utils.StdinSource:
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", err
+		}
+		return strings.TrimSpace(line), nil
+	case utils.ClipboardSource, "":
+		return clipboard.ReadAll()
+	}
+	return "", fmt.Errorf("Unknown URL source: %s.", URLSource)
 }
 
 func BytemarkFromURL() (utils.Bytemark, error) {
-	url, err := clipboard.ReadAll()
+	url, err := readURL()
 	if err != nil {
 		return utils.Bytemark{}, err
 	}
 
-	return createBytemark(url)
+	return CreateBytemark(url)
+}
+
+// Read URLs one per line, eg: from stdin. Blank lines and lines starting
+// with # are skipped.
+func ReadURLs(r io.Reader) ([]string, error) {
+	urls := make([]string, 0)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		urls = append(urls, line)
+	}
+	return urls, scanner.Err()
 }
@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Keep a copy of the previous version of a file as <file>.bak whenever
+// it is replaced.
+var KeepBackups = false
+
+// The filesystem calls made by WriteFileAtomic. Swapped out to inject
+// failures.
+var (
+	createTemp = ioutil.TempFile
+	writeTemp  = func(f *os.File, data []byte) (int, error) { return f.Write(data) }
+	syncTemp   = func(f *os.File) error { return f.Sync() }
+	renameFile = os.Rename
+)
+
+// The permissions of new files. Replaced files keep their own.
+const NEW_FILE_PERM = os.FileMode(0644)
+
+// Write data to a temporary file next to path, fsync it and rename it
+// over path, so path always holds either the old or the new contents.
+func WriteFileAtomic(path string, data []byte) error {
+	path = resolveSymlinks(path)
+	perm := filePerm(path)
+	if _, err := os.Stat(path); err == nil && KeepBackups {
+		if err := backup(path, perm); err != nil {
+			return err
+		}
+	}
+	return replaceFile(path, data, perm)
+}
+
+// The permissions of the file at path, or those of a new file if there
+// is none.
+func filePerm(path string) os.FileMode {
+	if info, err := os.Stat(path); err == nil {
+		return info.Mode().Perm()
+	}
+	return NEW_FILE_PERM
+}
+
+// The file path links to, so that replacing it keeps the link. path
+// itself if it is not a link or the link is broken.
+func resolveSymlinks(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return path
+}
+
+func backup(path string, perm os.FileMode) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return replaceFile(path+".bak", data, perm)
+}
+
+func replaceFile(path string, data []byte, perm os.FileMode) (err error) {
+	path = resolveSymlinks(path)
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	tmp, err := createTemp(dir, "."+base+".tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = writeTemp(tmp, data); err != nil {
+		return err
+	}
+	if err = tmp.Chmod(perm); err != nil {
+		return err
+	}
+	if err = syncTemp(tmp); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = renameFile(tmp.Name(), path); err != nil {
+		return err
+	}
+	syncDir(dir)
+	return nil
+}
+
+// Make the rename itself durable. Not every platform supports syncing a
+// directory, so errors are ignored.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
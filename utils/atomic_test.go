@@ -0,0 +1,133 @@
+package utils
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+var errInjected = errors.New("Injected failure.")
+
+// Restore the filesystem calls of WriteFileAtomic once t is done.
+func keepHooks(t *testing.T) {
+	create, write, sync, rename := createTemp, writeTemp, syncTemp, renameFile
+	t.Cleanup(func() {
+		createTemp, writeTemp, syncTemp, renameFile = create, write, sync, rename
+	})
+}
+
+func TestWriteFileAtomicFailures(t *testing.T) {
+	tests := []struct {
+		name   string
+		inject func()
+	}{
+		{"create", func() {
+			createTemp = func(string, string) (*os.File, error) { return nil, errInjected }
+		}},
+		{"write", func() {
+			writeTemp = func(*os.File, []byte) (int, error) { return 0, errInjected }
+		}},
+		{"sync", func() {
+			syncTemp = func(*os.File) error { return errInjected }
+		}},
+		{"rename", func() {
+			renameFile = func(string, string) error { return errInjected }
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			keepHooks(t)
+			dir := t.TempDir()
+			path := filepath.Join(dir, "list.md")
+			if err := ioutil.WriteFile(path, []byte("old"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			test.inject()
+			if err := WriteFileAtomic(path, []byte("new")); !errors.Is(err, errInjected) {
+				t.Fatalf("got error %v, want %v", err, errInjected)
+			}
+
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != "old" {
+				t.Errorf("got contents %q, want %q", data, "old")
+			}
+			entries, err := ioutil.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, entry := range entries {
+				if entry.Name() != "list.md" {
+					t.Errorf("left %s behind", entry.Name())
+				}
+			}
+		})
+	}
+}
+
+func TestWriteFileAtomicPerm(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no unix permissions")
+	}
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "new.md")
+	if err := WriteFileAtomic(path, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	if perm := filePerm(path); perm != NEW_FILE_PERM {
+		t.Errorf("new file has permissions %v, want %v", perm, NEW_FILE_PERM)
+	}
+
+	path = filepath.Join(dir, "private.md")
+	if err := ioutil.WriteFile(path, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	KeepBackups = true
+	defer func() { KeepBackups = false }()
+	if err := WriteFileAtomic(path, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{path, path + ".bak"} {
+		if perm := filePerm(p); perm != 0600 {
+			t.Errorf("%s has permissions %v, want %v", filepath.Base(p), perm, os.FileMode(0600))
+		}
+	}
+}
+
+func TestWriteFileAtomicSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target.md")
+	link := filepath.Join(dir, "link.md")
+	if err := ioutil.WriteFile(target, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Skip("cannot create symlinks:", err)
+	}
+
+	if err := WriteFileAtomic(link, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Lstat(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		t.Error("the link was replaced with a file")
+	}
+	data, err := ioutil.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "new" {
+		t.Errorf("got target contents %q, want %q", data, "new")
+	}
+}
@@ -2,33 +2,307 @@ package utils
 
 import (
 	"fmt"
-	"time"
+	"regexp"
 	"strings"
+	"time"
+)
+
+type ReadState string
+
+const (
+	Unread     ReadState = "unread"
+	InProgress ReadState = "in-progress"
+	Read       ReadState = "read"
 )
 
+// Labels for the named rows of a bytemark table, eg: | tags: go, tui |
+const (
+	SourceLabel   = "source"
+	CommentsLabel = "comments"
+	OriginalLabel = "original"
+	TagsLabel     = "tags"
+	NotesLabel    = "notes"
+	StateLabel    = "state"
+	ReadAtLabel   = "read_at"
+	AddedLabel    = "added"
+	UpdatedLabel  = "updated"
+
+	// Read from the page, see PageMeta.
+	DescriptionLabel = "description"
+	AuthorLabel      = "author"
+	PublishedLabel   = "published"
+	CanonicalLabel   = "canonical"
+	SiteLabel        = "site"
+	LangLabel        = "lang"
+	FaviconLabel     = "favicon"
+	ImageLabel       = "image"
+)
+
+const timestampLayout = time.RFC3339
+
+var labeledRowRegex = regexp.MustCompile(`^([A-Za-z][\w.-]*): (.*)$`)
+
 type Bytemark struct {
-	Title string
-	DateTime string
-	RootURL string
-	Rows []string
+	Title       string
+	DateTime    string
+	RootURL     string
+	Source      string // Where the bytemark came from, eg: hackernews, url.
+	CommentsURL string
+	OriginalURL string // The URL as given, if it was canonicalized.
+	Tags        []string
+	Notes       string
+	ReadState   ReadState
+	ReadAt      time.Time // When it was marked read.
+	Added       time.Time
+	Updated     time.Time
+	Page        PageMeta
+	Rows        []string // Other rows, kept as they were found.
+
+	// The rows after the URL as they were read: the label of a field as
+	// it was written, eg: Comments, or "" for the next of Rows. Tables are
+	// written back in this order.
+	layout []string
+
+	origin *origin
+	file   string // The file the bytemark belongs to, if known.
+}
+
+// What a page says about itself in its <head>: meta tags, OpenGraph and
+// Twitter cards.
+type PageMeta struct {
+	Description string
+	Author      string
+	Published   string // As given by the page.
+	Canonical   string
+	SiteName    string
+	Lang        string
+	Favicon     string
+	Image       string
+}
+
+// A labeled row of a bytemark, eg: tags: go, tui.
+type Field struct {
+	Label string
+	Value string
+}
+
+func (p *PageMeta) Fields() []Field {
+	fields := make([]Field, 0)
+	for _, field := range []Field{
+		{DescriptionLabel, p.Description},
+		{AuthorLabel, p.Author},
+		{PublishedLabel, p.Published},
+		{CanonicalLabel, p.Canonical},
+		{SiteLabel, p.SiteName},
+		{LangLabel, p.Lang},
+		{FaviconLabel, p.Favicon},
+		{ImageLabel, p.Image},
+	} {
+		if field.Value != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// The table a bytemark was parsed from. An unchanged bytemark is written
+// back exactly as it was found.
+type origin struct {
+	text  string // Verbatim, as it appeared in the file.
+	table string // What Table() returned right after parsing.
+}
+
+func labeledRow(label, value string) string {
+	return fmt.Sprintf("| %s: %s |\n", label, escapePipes(value))
+}
+
+func formatTimestamp(t time.Time) string {
+	return t.Format(timestampLayout)
+}
+
+// The labeled rows of b that are set, in the order they are written.
+func (b *Bytemark) Fields() []Field {
+	fields := make([]Field, 0)
+	add := func(label, value string) {
+		if value != "" {
+			fields = append(fields, Field{label, value})
+		}
+	}
+	add(SourceLabel, b.Source)
+	add(CommentsLabel, b.CommentsURL)
+	add(OriginalLabel, b.OriginalURL)
+	add(TagsLabel, strings.Join(b.Tags, ", "))
+	add(NotesLabel, b.Notes)
+	add(StateLabel, string(b.ReadState))
+	if !b.ReadAt.IsZero() {
+		add(ReadAtLabel, formatTimestamp(b.ReadAt))
+	}
+	if !b.Added.IsZero() {
+		add(AddedLabel, formatTimestamp(b.Added))
+	}
+	if !b.Updated.IsZero() {
+		add(UpdatedLabel, formatTimestamp(b.Updated))
+	}
+	fields = append(fields, b.Page.Fields()...)
+	return fields
+}
+
+// The rows of b after the URL: those that were read in the order and
+// with the labels they were read with, then the new ones.
+func (b *Bytemark) rows() string {
+	values := make(map[string]string)
+	for _, field := range b.Fields() {
+		values[field.Label] = field.Value
+	}
+	written := make(map[string]bool)
+	var rows string
+	next := 0
+	for _, label := range b.layout {
+		if label == "" {
+			if next < len(b.Rows) {
+				rows += fmt.Sprintf("| %s |\n", escapePipes(b.Rows[next]))
+				next++
+			}
+			continue
+		}
+		key := strings.ToLower(label)
+		if value, ok := values[key]; ok && !written[key] {
+			rows += labeledRow(label, value)
+			written[key] = true
+		}
+	}
+	for _, field := range b.Fields() {
+		if !written[field.Label] {
+			rows += labeledRow(field.Label, field.Value)
+		}
+	}
+	for _, row := range b.Rows[next:] {
+		rows += fmt.Sprintf("| %s |\n", escapePipes(row))
+	}
+	return rows
+}
+
+func escapePipes(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
+
+func unescapePipes(s string) string {
+	return strings.ReplaceAll(s, "\\|", "|")
 }
 
 func (b *Bytemark) Table() string {
-	b.Title = strings.ReplaceAll(b.Title, "|", "\\|") // escape | for markdown tables
 	table := fmt.Sprintf(
 		"| %s |\n| :-- |\n| %s |\n| %s |\n",
-		b.Title,
-		b.DateTime,
-		b.RootURL,
+		escapePipes(b.Title), // escape | for markdown tables
+		escapePipes(b.DateTime),
+		escapePipes(b.RootURL),
 	)
-	for _, row := range b.Rows {
-		table += fmt.Sprintf("| %s |\n", row)
-	}
+	table += b.rows()
 	table += "\n"
 
 	return table
 }
 
+// The file the bytemark was read from or will be saved to. Empty for new
+// bytemarks, which go to the default file of their hyperpath.
+func (b Bytemark) File() string {
+	return b.file
+}
+
+func (b *Bytemark) SetFile(path string) {
+	b.file = path
+}
+
+// The block of text the bytemark occupies in a file, without the blank
+// line that separates it from the next block.
+func (b *Bytemark) block() string {
+	table := b.Table()
+	if b.origin != nil && b.origin.table == table {
+		return b.origin.text
+	}
+	return strings.TrimSuffix(table, "\n")
+}
+
+// Whether b and other were read from the same table of a file, however
+// either was changed since.
+func (b Bytemark) SameOrigin(other Bytemark) bool {
+	return b.origin != nil && b.origin == other.origin
+}
+
+func splitTags(raw string) []string {
+	tags := make([]string, 0)
+	for _, tag := range strings.Split(raw, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// Sets a named field if the row is labeled with one of ours, otherwise
+// the row is kept in Rows. Older files wrote unlabeled rows, and the HN
+// comments link as "Comments: <url>", so labels are matched
+// case-insensitively.
+func (b *Bytemark) parseRow(row string) {
+	if label, ok := b.setRow(row); ok {
+		b.layout = append(b.layout, label)
+		return
+	}
+	b.Rows = append(b.Rows, unescapePipes(row))
+	b.layout = append(b.layout, "")
+}
+
+// Set the field row is labeled with, returning the label as written and
+// whether row was one of ours.
+func (b *Bytemark) setRow(row string) (string, bool) {
+	match := labeledRowRegex.FindStringSubmatch(row)
+	if match == nil {
+		return "", false
+	}
+	label, value := match[1], unescapePipes(strings.TrimSpace(match[2]))
+
+	var err error
+	switch strings.ToLower(label) {
+	case SourceLabel:
+		b.Source = value
+	case CommentsLabel:
+		b.CommentsURL = value
+	case OriginalLabel:
+		b.OriginalURL = value
+	case TagsLabel:
+		b.Tags = splitTags(value)
+	case NotesLabel:
+		b.Notes = value
+	case StateLabel:
+		b.ReadState = ReadState(value)
+	case ReadAtLabel:
+		b.ReadAt, err = time.Parse(timestampLayout, value)
+	case AddedLabel:
+		b.Added, err = time.Parse(timestampLayout, value)
+	case UpdatedLabel:
+		b.Updated, err = time.Parse(timestampLayout, value)
+	case DescriptionLabel:
+		b.Page.Description = value
+	case AuthorLabel:
+		b.Page.Author = value
+	case PublishedLabel:
+		b.Page.Published = value
+	case CanonicalLabel:
+		b.Page.Canonical = value
+	case SiteLabel:
+		b.Page.SiteName = value
+	case LangLabel:
+		b.Page.Lang = value
+	case FaviconLabel:
+		b.Page.Favicon = value
+	case ImageLabel:
+		b.Page.Image = value
+	default:
+		return "", false
+	}
+	return label, err == nil
+}
+
 func (b *Bytemark) SetDateTime(timeUsed time.Time) {
 	year, month, day := timeUsed.Date()
 	hour, min, _ := timeUsed.Clock()
@@ -36,12 +310,52 @@ func (b *Bytemark) SetDateTime(timeUsed time.Time) {
 	dateString := fmt.Sprintf("%d/%d/%d", month, day, year)
 	timeString := fmt.Sprintf("%d:%d", hour, min)
 	b.DateTime = fmt.Sprintf("%s %s", dateString, timeString)
+	if b.Added.IsZero() {
+		b.Added = timeUsed.Truncate(time.Second)
+	}
 }
 
 func (b *Bytemark) SetDateTimeNow() {
 	b.SetDateTime(time.Now())
 }
 
+// Record that the bytemark was changed.
+func (b *Bytemark) Touch() {
+	b.Updated = time.Now().Truncate(time.Second)
+}
+
+// The state named by s, eg: in-progress.
+func ParseReadState(s string) (ReadState, error) {
+	switch state := ReadState(strings.ToLower(s)); state {
+	case Unread, InProgress, Read:
+		return state, nil
+	}
+	return "", fmt.Errorf("Unknown state '%s': use unread, in-progress or read.", s)
+}
+
+// Set the read state. Marking a bytemark read records when; marking it
+// unread again forgets it.
+func (b *Bytemark) SetReadState(state ReadState) {
+	if state == Read && b.ReadState != Read {
+		b.ReadAt = time.Now().Truncate(time.Second)
+	} else if state != Read {
+		b.ReadAt = time.Time{}
+	}
+	b.ReadState = state
+	b.Touch()
+}
+
+// The state after s: unread, in-progress, read and back to unread.
+func (s ReadState) Next() ReadState {
+	switch s {
+	case InProgress:
+		return Read
+	case Read:
+		return Unread
+	}
+	return InProgress
+}
+
 // Returns whether or not the title of the article contains the search
 // string. Can be improved upon later -> punctuation can create annoying
 // situations.
@@ -59,6 +373,17 @@ func DeleteBytemark(original []Bytemark, index int) []Bytemark {
 	return deleted
 }
 
+// Delete the bytemarks at indices.
+func DeleteBytemarks(original []Bytemark, indices []int) []Bytemark {
+	deleted := make([]Bytemark, 0)
+	for i, element := range original {
+		if !contains(indices, i) {
+			deleted = append(deleted, element)
+		}
+	}
+	return deleted
+}
+
 func SwapBytemarks(original []Bytemark, indexA, indexB int) []Bytemark {
 	swapped := make([]Bytemark, len(original))
 	for i := 0; i < len(original); i++ {
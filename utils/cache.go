@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const PAGES_DIR = "pages"
+
+// What was read from a page when it was last fetched, kept so that it can
+// be shown without fetching the page again.
+type CachedPage struct {
+	URL     string    `json:"url"`
+	Fetched time.Time `json:"fetched"`
+	Title   string    `json:"title"`
+	Page    PageMeta  `json:"page"`
+	Excerpt string    `json:"excerpt"` // The start of the text of the page.
+}
+
+// Cached pages are kept in the user's cache directory, eg: ~/.cache.
+func CacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, CONFIG_DIR, PAGES_DIR), nil
+}
+
+func cachedPagePath(url string) (string, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, hashString([]byte(url))+".json"), nil
+}
+
+// The cached page of url, and whether there is one.
+func ReadCachedPage(url string) (CachedPage, bool, error) {
+	var page CachedPage
+	path, err := cachedPagePath(url)
+	if err != nil {
+		return page, false, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return page, false, nil
+	} else if err != nil {
+		return page, false, err
+	}
+	if err := json.Unmarshal(data, &page); err != nil {
+		return page, false, err
+	}
+	return page, true, nil
+}
+
+// Cache page under its URL, replacing what was cached before.
+func WriteCachedPage(page CachedPage) error {
+	path, err := cachedPagePath(page.URL)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(page)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return replaceFile(path, data, 0644)
+}
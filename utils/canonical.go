@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// Query parameters that only track where a link was clicked. A * matches
+// any run of characters, eg: utm_*
+var DefaultTrackingParams = []string{
+	"utm_*", "fbclid", "gclid", "dclid", "msclkid", "yclid", "igshid",
+	"mc_cid", "mc_eid", "_hsenc", "_hsmi", "ref", "ref_src", "ref_url",
+}
+
+// Set from the config, see URLSettings.
+var (
+	TrackingParams  = DefaultTrackingParams
+	FollowRedirects = false
+)
+
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+func isTrackingParam(name string) bool {
+	for _, pattern := range TrackingParams {
+		if matched, _ := path.Match(pattern, strings.ToLower(name)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// The form of raw that is used to tell whether two bytemarks point to
+// the same page: the scheme and host are lowercased, the default port,
+// fragment and tracking parameters are removed and the query is sorted.
+// Fragments that are routes, eg: #!/page or #/page, are kept.
+func CanonicalURL(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", fmt.Errorf("Invalid URL: %s", raw)
+	}
+	if u.Host == "" {
+		// Not something that can be canonicalized, eg: a mailto: link.
+		return u.String(), nil
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]" // IPv6
+	}
+	if port := u.Port(); port != "" && port != defaultPorts[u.Scheme] {
+		host += ":" + port
+	}
+	u.Host = host
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	if !strings.HasPrefix(u.Fragment, "!") && !strings.HasPrefix(u.Fragment, "/") {
+		u.Fragment = ""
+		u.RawFragment = ""
+	}
+
+	query := u.Query()
+	for name := range query {
+		if isTrackingParam(name) {
+			query.Del(name)
+		}
+	}
+	u.RawQuery = query.Encode() // Sorted by name.
+	u.ForceQuery = false
+	return u.String(), nil
+}
+
+// The URL raw ends up at after following its redirects.
+func FinalURL(raw string, timeout time.Duration) (string, error) {
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Head(raw)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	return resp.Request.URL.String(), nil
+}
+
+// Canonicalize the URL of b, following its redirects if FollowRedirects
+// is set.
+func (b *Bytemark) CanonicalizeURL(timeout time.Duration) error {
+	raw := b.RootURL
+	if FollowRedirects {
+		final, err := FinalURL(raw, timeout)
+		if err != nil {
+			return err
+		}
+		raw = final
+	}
+	return b.SetCanonicalURL(raw)
+}
+
+// Replace the URL of b with the canonical form of raw, which is where the
+// URL of b leads. The URL b had is kept in OriginalURL if it changed.
+func (b *Bytemark) SetCanonicalURL(raw string) error {
+	canonical, err := CanonicalURL(raw)
+	if err != nil {
+		return err
+	}
+	if canonical != b.RootURL && b.OriginalURL == "" {
+		b.OriginalURL = b.RootURL
+	}
+	b.RootURL = canonical
+	return nil
+}
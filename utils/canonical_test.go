@@ -0,0 +1,77 @@
+package utils
+
+import "testing"
+
+func TestCanonicalURL(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"https://example.com/a", "https://example.com/a"},
+		{"  https://example.com/a  ", "https://example.com/a"},
+		{"HTTPS://Example.COM/Path", "https://example.com/Path"},
+		{"https://example.com", "https://example.com/"},
+		{"https://example.com:443/a", "https://example.com/a"},
+		{"http://example.com:80/a", "http://example.com/a"},
+		{"https://example.com:8443/a", "https://example.com:8443/a"},
+		{"http://[::1]:8080/a", "http://[::1]:8080/a"},
+		{"https://example.com/a#section", "https://example.com/a"},
+		{"https://example.com/#!/page", "https://example.com/#!/page"},
+		{"https://example.com/#/page", "https://example.com/#/page"},
+		{"https://example.com/a?b=2&a=1", "https://example.com/a?a=1&b=2"},
+		{"https://example.com/a?", "https://example.com/a"},
+		{"https://example.com/a?utm_source=x&utm_medium=y&id=3", "https://example.com/a?id=3"},
+		{"https://example.com/a?UTM_Source=x&id=3", "https://example.com/a?id=3"},
+		{"https://example.com/a?fbclid=x&gclid=y&ref=z", "https://example.com/a"},
+		{"https://example.com/a?reference=1", "https://example.com/a?reference=1"},
+		{"mailto:me@example.com", "mailto:me@example.com"},
+	}
+
+	for _, test := range tests {
+		got, err := CanonicalURL(test.raw)
+		if err != nil {
+			t.Errorf("%s: %v", test.raw, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: got %s, want %s", test.raw, got, test.want)
+		}
+	}
+}
+
+func TestCanonicalURLTrackingParams(t *testing.T) {
+	defer func(params []string) { TrackingParams = params }(TrackingParams)
+	TrackingParams = []string{"src", "x_*"}
+
+	got, err := CanonicalURL("https://example.com/?src=a&x_y=b&utm_source=c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "https://example.com/?utm_source=c"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestCanonicalURLInvalid(t *testing.T) {
+	if _, err := CanonicalURL("http://a b.com/%zz"); err == nil {
+		t.Error("an invalid URL was canonicalized")
+	}
+}
+
+func TestSetCanonicalURL(t *testing.T) {
+	b := Bytemark{RootURL: "https://Example.com/a?utm_source=x"}
+	if err := b.SetCanonicalURL(b.RootURL); err != nil {
+		t.Fatal(err)
+	}
+	if b.RootURL != "https://example.com/a" || b.OriginalURL != "https://Example.com/a?utm_source=x" {
+		t.Errorf("got %s (original %s)", b.RootURL, b.OriginalURL)
+	}
+
+	b = Bytemark{RootURL: "https://example.com/a"}
+	if err := b.SetCanonicalURL(b.RootURL); err != nil {
+		t.Fatal(err)
+	}
+	if b.OriginalURL != "" {
+		t.Errorf("kept original %s of a canonical URL", b.OriginalURL)
+	}
+}
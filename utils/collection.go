@@ -0,0 +1,340 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+// The documents of a hyperpath, which can be more than one file if the
+// hyperpath is a directory or a glob. Bytemarks are saved back to the
+// file they came from.
+type Collection struct {
+	Hyperpath   Hyperpath
+	Documents   []*Document
+	DefaultFile string
+}
+
+func LoadCollection(hp Hyperpath) (*Collection, error) {
+	files, err := hp.Files()
+	if err != nil {
+		return nil, err
+	}
+	defaultFile, err := hp.DefaultFile()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Collection{Hyperpath: hp, DefaultFile: defaultFile}
+	if PathExists(defaultFile) && !containsString(files, defaultFile) {
+		// A default file that the glob does not match.
+		files = append(files, defaultFile)
+	}
+	for _, file := range files {
+		doc, err := LoadDocument(file)
+		if err != nil {
+			return nil, err
+		}
+		c.Documents = append(c.Documents, doc)
+	}
+	return c, nil
+}
+
+func (c *Collection) document(path string) *Document {
+	for _, doc := range c.Documents {
+		if doc.Path == path {
+			return doc
+		}
+	}
+	return nil
+}
+
+// All bytemarks, grouped by file.
+func (c *Collection) Bytemarks() []Bytemark {
+	bytemarks := make([]Bytemark, 0)
+	for _, doc := range c.Documents {
+		bytemarks = append(bytemarks, doc.Bytemarks()...)
+	}
+	return bytemarks
+}
+
+// Hand each bytemark to the document of its file, keeping their order.
+// Bytemarks without a file go to the default file.
+func (c *Collection) SetBytemarks(bytemarks []Bytemark) {
+	byFile := make(map[string][]Bytemark)
+	for _, bytemark := range bytemarks {
+		file := bytemark.file
+		if file == "" || (c.document(file) == nil && file != c.DefaultFile) {
+			file = c.DefaultFile
+		}
+		bytemark.file = file
+		byFile[file] = append(byFile[file], bytemark)
+	}
+
+	if _, ok := byFile[c.DefaultFile]; ok && c.document(c.DefaultFile) == nil {
+		// The default file does not exist yet, it is created on save.
+		c.Documents = append(c.Documents, &Document{Path: c.DefaultFile})
+	}
+	for _, doc := range c.Documents {
+		doc.SetBytemarks(byFile[doc.Path])
+	}
+}
+
+func (c *Collection) Diagnostics() []Diagnostic {
+	diagnostics := make([]Diagnostic, 0)
+	for _, doc := range c.Documents {
+		diagnostics = append(diagnostics, doc.Diagnostics...)
+	}
+	return diagnostics
+}
+
+func (c *Collection) Quarantined() []Quarantined {
+	quarantined := make([]Quarantined, 0)
+	for _, doc := range c.Documents {
+		quarantined = append(quarantined, doc.Quarantined()...)
+	}
+	return quarantined
+}
+
+// Save the documents that were changed. Nothing is written if any of
+// them was changed on disk since it was loaded (ErrConflict).
+func (c *Collection) Save() error {
+	return saveCollections(false, c)
+}
+
+func (c *Collection) Overwrite() error {
+	return saveCollections(true, c)
+}
+
+// ErrConflict if a changed document was changed on disk since it was
+// loaded.
+func (c *Collection) checkConflicts() error {
+	for _, doc := range c.Documents {
+		if !doc.Modified() {
+			continue
+		}
+		changed, err := doc.changedOnDisk()
+		if err != nil {
+			return err
+		}
+		if changed && doc.loaded != nil {
+			return ErrConflict
+		}
+	}
+	return nil
+}
+
+// Save the collections as one change: nothing is written if any of them
+// has a conflict, the files are journaled as one save, and if one cannot
+// be saved, the files of the others are put back the way they were.
+func SaveCollections(collections ...*Collection) error {
+	return saveCollections(false, collections...)
+}
+
+func saveCollections(force bool, collections ...*Collection) error {
+	before := make(snapshot)
+	writes := make(map[string][]byte)
+	for _, c := range collections {
+		if !force {
+			if err := c.checkConflicts(); err != nil {
+				return err
+			}
+		}
+		files, err := c.snapshot()
+		if err != nil {
+			return err
+		}
+		for path, data := range files {
+			before[path] = data
+		}
+		for _, doc := range c.Documents {
+			if doc.Modified() {
+				writes[doc.Path] = []byte(doc.String())
+			}
+		}
+	}
+	lock, err := lockJournal()
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+	if err := journalWrites(writes); err != nil {
+		return err
+	}
+
+	for _, c := range collections {
+		if err := c.save(force); err != nil {
+			return before.restoreAfter(err)
+		}
+	}
+	return nil
+}
+
+// Write the changed documents of c, see saveCollections.
+func (c *Collection) save(force bool) error {
+	for _, doc := range c.Documents {
+		if !doc.Modified() {
+			continue
+		}
+		var err error
+		if force {
+			err = doc.Overwrite()
+		} else {
+			err = doc.Save()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// See Document.Merge.
+func (c *Collection) Merge() error {
+	for _, doc := range c.Documents {
+		if doc.loaded == nil {
+			continue
+		}
+		if _, err := os.Stat(doc.Path); os.IsNotExist(err) {
+			continue
+		}
+		if err := doc.Merge(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Remove the bytemarks at indices (see Bytemarks).
+func (c *Collection) RemoveBytemarks(indices []int) {
+	kept := make([]Bytemark, 0)
+	for i, bytemark := range c.Bytemarks() {
+		if !contains(indices, i) {
+			kept = append(kept, bytemark)
+		}
+	}
+	c.SetBytemarks(kept)
+}
+
+// Add bytemarks to the default file of c and save it. The ones that c
+// already has are dealt with according to Duplicates.
+func (c *Collection) AddBytemarks(bytemarks []Bytemark) ([]Duplicate, error) {
+	duplicates := c.addBytemarks(bytemarks)
+	return duplicates, c.Save()
+}
+
+func (c *Collection) addBytemarks(bytemarks []Bytemark) []Duplicate {
+	all := c.Bytemarks()
+	saved := len(all)
+	duplicates := make([]Duplicate, 0)
+	for _, bytemark := range bytemarks {
+		bytemark.file = ""
+		i, found := findNewDuplicate(all, bytemark)
+		if !found {
+			all = append(all, bytemark)
+			continue
+		}
+
+		existing := Location{Index: i, Bytemark: all[i]}
+		if i < saved {
+			existing.Collection = c
+		}
+		duplicates = append(duplicates, Duplicate{bytemark, existing, Duplicates})
+		switch Duplicates {
+		case DuplicateWarn:
+			all = append(all, bytemark)
+		case DuplicateMerge:
+			all[i] = MergeBytemarks(all[i], bytemark)
+		}
+	}
+	c.SetBytemarks(all)
+	return duplicates
+}
+
+// Copy the bytemarks of src at indices to dst (see AddBytemarks), and
+// remove them from src if move is set (see MoveBytemarks).
+func SendBytemarks(src, dst *Collection, indices []int, move bool) ([]Duplicate, error) {
+	sent := make([]Bytemark, 0, len(indices))
+	kept := make([]Bytemark, 0)
+	for i, bytemark := range src.Bytemarks() {
+		if contains(indices, i) {
+			sent = append(sent, bytemark)
+		} else {
+			kept = append(kept, bytemark)
+		}
+	}
+	if !move {
+		return dst.AddBytemarks(sent)
+	}
+	return MoveBytemarks(src, kept, dst, sent)
+}
+
+// Add moved to dst and leave src with kept, as one change (see
+// SaveCollections), so a bytemark is never lost or left in both places.
+func MoveBytemarks(src *Collection, kept []Bytemark, dst *Collection, moved []Bytemark) ([]Duplicate, error) {
+	duplicates := dst.addBytemarks(moved)
+	src.SetBytemarks(kept)
+	return duplicates, SaveCollections(dst, src)
+}
+
+// The files of a collection as they are on disk. A nil entry is a file
+// that does not exist yet.
+type snapshot map[string][]byte
+
+// A snapshot of the files that saving c would write.
+func (c *Collection) snapshot() (snapshot, error) {
+	files := make(snapshot)
+	for _, doc := range c.Documents {
+		if !doc.Modified() {
+			continue
+		}
+		data, err := ioutil.ReadFile(doc.Path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		files[doc.Path] = data
+	}
+	return files, nil
+}
+
+// Put the files back after a save failed with err, see restore.
+func (s snapshot) restoreAfter(err error) error {
+	if restoreErr := s.restore(); restoreErr != nil {
+		return fmt.Errorf("Could not undo the save after '%v': %v.", err, restoreErr)
+	}
+	return err
+}
+
+// Put every file back the way it was when the snapshot was taken. Files
+// that were not written since are left alone.
+func (s snapshot) restore() error {
+	for path, data := range s {
+		current, err := ioutil.ReadFile(path)
+		if os.IsNotExist(err) && data == nil || err == nil && data != nil && bytes.Equal(current, data) {
+			continue
+		}
+		if err := restoreFile(path, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func restoreFile(path string, data []byte) error {
+	lock, err := LockFile(path)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	if data == nil {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	// Not WriteFileAtomic, which would replace the backup with the
+	// version being undone.
+	return replaceFile(path, data, filePerm(resolveSymlinks(path)))
+}
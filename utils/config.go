@@ -0,0 +1,160 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	CONFIG_ENV  = "HYPERMARK_CONFIG"
+	CONFIG_DIR  = "hypermark"
+	CONFIG_FILE = "config.toml"
+)
+
+// Where the URL for a bytemark is read from by default.
+const (
+	ClipboardSource = "clipboard"
+	StdinSource     = "stdin"
+)
+
+type Defaults struct {
+	Overwrite bool   `toml:"overwrite"`
+	Clipboard bool   `toml:"clipboard"`
+	Source    string `toml:"source"`
+	Backup    bool   `toml:"backup"`
+
+	// What to do with new bytemarks that are already saved, see
+	// DuplicatePolicy.
+	Duplicates string `toml:"duplicates"`
+
+	// Name of the hyperpath that bytemarks are moved to once read.
+	Archive string `toml:"archive"`
+
+	// How many saves are kept for hypermark undo, see JournalSize.
+	Journal int `toml:"journal"`
+
+	// Whether opening a bytemark marks it in-progress, see MarkOpened.
+	MarkOpened bool `toml:"mark_opened"`
+}
+
+// How the URLs of new bytemarks are canonicalized, see CanonicalURL.
+type URLSettings struct {
+	TrackingParams  []string `toml:"tracking_params"`
+	FollowRedirects bool     `toml:"follow_redirects"`
+}
+
+type Config struct {
+	Defaults   Defaults    `toml:"defaults"`
+	URLs       URLSettings `toml:"urls"`
+	Hyperpaths []Hyperpath `toml:"hyperpaths"`
+}
+
+// Set with --config; takes precedence over the environment.
+var configPath string
+
+func SetConfigPath(path string) {
+	configPath = ExpandTilde(path)
+}
+
+// The config file in use: --config, then $HYPERMARK_CONFIG, then
+// $XDG_CONFIG_HOME/hypermark/config.toml.
+func ConfigPath() (string, error) {
+	if configPath != "" {
+		return configPath, nil
+	}
+	if path := os.Getenv(CONFIG_ENV); path != "" {
+		return ExpandTilde(path), nil
+	}
+	dir, err := os.UserConfigDir() // $XDG_CONFIG_HOME or ~/.config
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, CONFIG_DIR, CONFIG_FILE), nil
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		Defaults: Defaults{
+			Source:     ClipboardSource,
+			Duplicates: string(DuplicateWarn),
+			Journal:    DefaultJournalSize,
+		},
+		URLs:       URLSettings{TrackingParams: DefaultTrackingParams},
+		Hyperpaths: make([]Hyperpath, 0),
+	}
+}
+
+// Read the config file. The first time it is missing, the hyperpaths of
+// a legacy hyperpaths file are moved into it.
+func LoadConfig() (*Config, error) {
+	path, err := ConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := defaultConfig()
+	if !PathExists(path) {
+		if !PathExists(LEGACY_HP_FILEPATH) {
+			return cfg, nil
+		}
+		return migrateLegacyHyperpaths(cfg)
+	}
+
+	if _, err := toml.DecodeFile(path, cfg); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if nameHyperpaths(cfg.Hyperpaths) {
+		// Save the names so they stay the same from now on.
+		if err := cfg.Save(); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+func migrateLegacyHyperpaths(cfg *Config) (*Config, error) {
+	hyperpaths, err := readLegacyHyperpaths(LEGACY_HP_FILEPATH)
+	if err != nil {
+		return nil, err
+	}
+	// Relative paths were relative to where hypermark was run, which is
+	// where the legacy file is.
+	for _, hyperpath := range hyperpaths {
+		if hyperpath != "" {
+			cfg.Hyperpaths = append(cfg.Hyperpaths, Hyperpath{Path: AbsPath(hyperpath)})
+		}
+	}
+	nameHyperpaths(cfg.Hyperpaths)
+	if err := cfg.Save(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) Save() error {
+	path, err := ConfigPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	enc := toml.NewEncoder(&buf)
+	enc.Indent = ""
+	if err := enc.Encode(c); err != nil {
+		return err
+	}
+
+	lock, err := LockFile(path)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+	return WriteFileAtomic(path, buf.Bytes())
+}
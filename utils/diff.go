@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// How a line of a diff changed.
+const (
+	DiffSame    = ' '
+	DiffRemoved = '-'
+	DiffAdded   = '+'
+)
+
+type DiffLine struct {
+	Kind byte
+	Text string
+}
+
+// The changes saving would make to a file.
+type FileDiff struct {
+	Path  string
+	Lines []DiffLine
+}
+
+// The lines of b compared to those of a, from their longest common
+// subsequence. A save usually changes a few lines of a long file, so the
+// lines the two start and end with are left out of the search.
+func Diff(a, b string) []DiffLine {
+	x := splitLines(a)
+	y := splitLines(b)
+
+	prefix := 0
+	for prefix < len(x) && prefix < len(y) && x[prefix] == y[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(x)-prefix && suffix < len(y)-prefix &&
+		x[len(x)-1-suffix] == y[len(y)-1-suffix] {
+		suffix++
+	}
+
+	lines := make([]DiffLine, 0, len(x)+len(y))
+	for _, line := range x[:prefix] {
+		lines = append(lines, DiffLine{DiffSame, line})
+	}
+	lines = append(lines, lcsDiff(x[prefix:len(x)-suffix], y[prefix:len(y)-suffix])...)
+	for _, line := range x[len(x)-suffix:] {
+		lines = append(lines, DiffLine{DiffSame, line})
+	}
+	return lines
+}
+
+func lcsDiff(x, y []string) []DiffLine {
+	// common[i][j] is the length of the LCS of x[i:] and y[j:].
+	common := make([][]int, len(x)+1)
+	for i := range common {
+		common[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else if common[i+1][j] >= common[i][j+1] {
+				common[i][j] = common[i+1][j]
+			} else {
+				common[i][j] = common[i][j+1]
+			}
+		}
+	}
+
+	lines := make([]DiffLine, 0, len(x)+len(y))
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		switch {
+		case x[i] == y[j]:
+			lines = append(lines, DiffLine{DiffSame, x[i]})
+			i++
+			j++
+		case common[i+1][j] >= common[i][j+1]:
+			lines = append(lines, DiffLine{DiffRemoved, x[i]})
+			i++
+		default:
+			lines = append(lines, DiffLine{DiffAdded, y[j]})
+			j++
+		}
+	}
+	for ; i < len(x); i++ {
+		lines = append(lines, DiffLine{DiffRemoved, x[i]})
+	}
+	for ; j < len(y); j++ {
+		lines = append(lines, DiffLine{DiffAdded, y[j]})
+	}
+	return lines
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// What saving the collection would change on disk, one diff per file
+// that would be written.
+func (c *Collection) Changes() ([]FileDiff, error) {
+	diffs := make([]FileDiff, 0)
+	for _, doc := range c.Documents {
+		if !doc.Modified() {
+			continue
+		}
+		data, err := ioutil.ReadFile(doc.Path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		diffs = append(diffs, FileDiff{
+			Path:  doc.Path,
+			Lines: Diff(string(data), doc.String()),
+		})
+	}
+	return diffs, nil
+}
@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want []DiffLine
+	}{
+		{"", "", []DiffLine{}},
+		{"a\nb\nc\n", "a\nc\n", []DiffLine{{DiffSame, "a"}, {DiffRemoved, "b"}, {DiffSame, "c"}}},
+		{"a\nb\n", "a\nb\nc\n", []DiffLine{{DiffSame, "a"}, {DiffSame, "b"}, {DiffAdded, "c"}}},
+		{"x\n", "", []DiffLine{{DiffRemoved, "x"}}},
+		{"a\na\n", "a\n", []DiffLine{{DiffSame, "a"}, {DiffRemoved, "a"}}},
+		{"a\nb\nd\na\n", "a\nc\nd\na\n", []DiffLine{
+			{DiffSame, "a"}, {DiffRemoved, "b"}, {DiffAdded, "c"}, {DiffSame, "d"}, {DiffSame, "a"},
+		}},
+	}
+
+	for _, test := range tests {
+		if got := Diff(test.a, test.b); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q to %q: got %q, want %q", test.a, test.b, got, test.want)
+		}
+	}
+}
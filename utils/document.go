@@ -0,0 +1,293 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+// The file was changed by someone else since it was loaded.
+var ErrConflict = errors.New("file changed on disk since it was loaded")
+
+// A hyperpath file as a list of blocks separated by blank lines.
+// Blocks that are not bytemark tables (headings, prose, other tables)
+// are kept verbatim, as are the blank lines between blocks, so a file
+// that is loaded and saved only changes where its bytemarks changed.
+type Document struct {
+	Path        string
+	Diagnostics []Diagnostic
+	blocks      []block
+	trailer     string // Blank lines after the last block.
+
+	// The file as it was when loaded, to detect changes made by others.
+	modTime time.Time
+	hash    [sha256.Size]byte
+	loaded  map[string]bool // Tables of the bytemarks that were loaded.
+}
+
+type block struct {
+	lead       string // Blank lines before the block, verbatim.
+	text       string
+	line       int       // Line the block starts on.
+	bytemark   *Bytemark // nil if the block is not a bytemark.
+	quarantine *Quarantined
+}
+
+// A table that looks like a bytemark but could not be read as one. It
+// is written back untouched so nothing is lost.
+type Quarantined struct {
+	Text        string
+	Line        int
+	Diagnostics []Diagnostic
+}
+
+func isBlankLine(line string) bool {
+	return strings.TrimSpace(strings.Trim(line, "\x00")) == ""
+}
+
+func ParseDocument(path string, data []byte) *Document {
+	doc := &Document{Path: path}
+
+	var lead, text string
+	var lineNum, startLine int
+	closeBlock := func() {
+		blk := block{lead: lead, text: text, line: startLine}
+		if isBytemarkTable(text) {
+			bytemark, diagnostics := parseTable(path, text, startLine)
+			if len(diagnostics) == 0 {
+				bytemark.origin = &origin{text: text, table: bytemark.Table()}
+				bytemark.file = path
+				blk.bytemark = &bytemark
+			} else {
+				blk.quarantine = &Quarantined{
+					Text:        text,
+					Line:        startLine,
+					Diagnostics: diagnostics,
+				}
+				doc.Diagnostics = append(doc.Diagnostics, diagnostics...)
+			}
+		}
+		doc.blocks = append(doc.blocks, blk)
+		lead, text = "", ""
+	}
+
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		if line == "" {
+			continue
+		}
+		lineNum++
+		if isBlankLine(line) {
+			if text != "" {
+				closeBlock()
+			}
+			lead += line
+		} else {
+			if text == "" {
+				startLine = lineNum
+			}
+			text += line
+		}
+	}
+	if text != "" {
+		closeBlock()
+	}
+	doc.trailer = lead
+	return doc
+}
+
+func LoadDocument(path string) (*Document, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	doc := ParseDocument(path, data)
+	if err := doc.setLoaded(data); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (d *Document) setLoaded(data []byte) error {
+	info, err := os.Stat(d.Path)
+	if err != nil {
+		return err
+	}
+	d.modTime = info.ModTime()
+	d.hash = sha256.Sum256(data)
+	d.loaded = make(map[string]bool)
+	for _, bytemark := range d.Bytemarks() {
+		d.loaded[bytemark.Table()] = true
+	}
+	return nil
+}
+
+// Whether the file on disk is no longer what was loaded.
+func (d *Document) changedOnDisk() (bool, error) {
+	info, err := os.Stat(d.Path)
+	if os.IsNotExist(err) {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+	if info.ModTime().Equal(d.modTime) {
+		return false, nil
+	}
+	// The mtime alone is not enough, eg: touch(1).
+	data, err := ioutil.ReadFile(d.Path)
+	if err != nil {
+		return false, err
+	}
+	return sha256.Sum256(data) != d.hash, nil
+}
+
+func (d *Document) Bytemarks() []Bytemark {
+	bytemarks := make([]Bytemark, 0)
+	for _, blk := range d.blocks {
+		if blk.bytemark != nil {
+			bytemarks = append(bytemarks, *blk.bytemark)
+		}
+	}
+	return bytemarks
+}
+
+// Tables that could not be loaded as bytemarks.
+func (d *Document) Quarantined() []Quarantined {
+	quarantined := make([]Quarantined, 0)
+	for _, blk := range d.blocks {
+		if blk.quarantine != nil {
+			quarantined = append(quarantined, *blk.quarantine)
+		}
+	}
+	return quarantined
+}
+
+func newBytemarkBlock(bytemark Bytemark) block {
+	return block{
+		lead:     "\n",
+		text:     bytemark.block(),
+		bytemark: &bytemark,
+	}
+}
+
+// Replace the bytemarks of the document. The bytemarks fill the slots of
+// the old bytemark tables in order; extra slots are dropped and extra
+// bytemarks are placed after the last slot (or at the end of the file).
+func (d *Document) SetBytemarks(bytemarks []Bytemark) {
+	blocks := make([]block, 0, len(d.blocks)+len(bytemarks))
+	next := 0
+	insertAt := -1
+	for _, blk := range d.blocks {
+		if blk.bytemark == nil {
+			blocks = append(blocks, blk)
+			continue
+		}
+		if next == len(bytemarks) {
+			continue
+		}
+		bytemark := bytemarks[next]
+		blk.text = bytemark.block()
+		blk.bytemark = &bytemark
+		blocks = append(blocks, blk)
+		insertAt = len(blocks)
+		next++
+	}
+
+	if insertAt == -1 {
+		insertAt = len(blocks)
+	}
+	extra := make([]block, 0)
+	for ; next < len(bytemarks); next++ {
+		extra = append(extra, newBytemarkBlock(bytemarks[next]))
+	}
+	if len(extra) != 0 {
+		if insertAt == len(blocks) && d.trailer == "" {
+			// Leave a blank line so that appending to the file works.
+			d.trailer = "\n"
+		}
+		blocks = append(blocks[:insertAt], append(extra, blocks[insertAt:]...)...)
+	}
+	if len(blocks) != 0 && (len(d.blocks) == 0 || d.blocks[0].lead == "") {
+		blocks[0].lead = ""
+	}
+	d.blocks = blocks
+}
+
+func (d *Document) String() string {
+	var s string
+	for i, blk := range d.blocks {
+		if i > 0 && !strings.HasSuffix(s, "\n") {
+			s += "\n"
+		}
+		if i > 0 && blk.lead == "" {
+			s += "\n"
+		}
+		s += blk.lead + blk.text
+	}
+	if d.trailer != "" && !strings.HasSuffix(s, "\n") {
+		s += "\n"
+	}
+	return s + d.trailer
+}
+
+// Whether saving would change the file as it was loaded.
+func (d *Document) Modified() bool {
+	return d.loaded == nil || sha256.Sum256([]byte(d.String())) != d.hash
+}
+
+// Write the document to its file. Returns ErrConflict, and writes
+// nothing, if the file was changed since it was loaded.
+func (d *Document) Save() error {
+	return d.save(false)
+}
+
+// Write the document to its file even if it was changed on disk.
+func (d *Document) Overwrite() error {
+	return d.save(true)
+}
+
+func (d *Document) save(force bool) error {
+	lock, err := LockFile(d.Path)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	if !force && d.loaded != nil {
+		changed, err := d.changedOnDisk()
+		if err != nil {
+			return err
+		}
+		if changed {
+			return ErrConflict
+		}
+	}
+
+	data := []byte(d.String())
+	if err := WriteFileAtomic(d.Path, data); err != nil {
+		return err
+	}
+	return d.setLoaded(data)
+}
+
+// Bring in the changes made to the file on disk since it was loaded.
+// Bytemarks added on disk are appended to the bytemarks of d, and the
+// rest of the file is taken from disk.
+func (d *Document) Merge() error {
+	disk, err := LoadDocument(d.Path)
+	if err != nil {
+		return err
+	}
+
+	bytemarks := d.Bytemarks()
+	for _, bytemark := range disk.Bytemarks() {
+		if !d.loaded[bytemark.Table()] {
+			bytemarks = append(bytemarks, bytemark)
+		}
+	}
+	disk.SetBytemarks(bytemarks)
+	*d = *disk
+	return nil
+}
@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	tableA = "| A |\n| :-- |\n| 1/1/2021 3:00 |\n| https://a.example/ |\n"
+	tableB = "| B |\n| :-- |\n| 2/1/2021 14:5 |\n| https://b.example/ |\n| Comments: https://news.ycombinator.com/item?id=2 |\n"
+)
+
+func TestDocumentRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"empty", ""},
+		{"one bytemark", tableA},
+		{"no trailing newline", strings.TrimSuffix(tableA, "\n")},
+		{"blank lines", "\n\n" + tableA + "\n\n\n" + tableB + "\n\n"},
+		{"blank lines with spaces", tableA + "  \n\t\n" + tableB},
+		{"other blocks", "# Reading\n\nSome prose\nover two lines.\n\n" + tableA +
+			"\n| a | b |\n| - | - |\n| 1 | 2 |\n\n" + tableB + "\n## Done\n"},
+		{"quarantined", tableA + "\n| Bad | pipe |\n| :-- |\n| 1/1/2021 3:00 |\n| https://c.example/ |\n\n" + tableB},
+		{"missing URL", "| No URL |\n| :-- |\n| 1/1/2021 3:00 |\n\n" + tableA},
+		{"windows line endings", strings.ReplaceAll(tableA+"\n"+tableB, "\n", "\r\n")},
+		{"unlabeled and unknown rows", "| C |\n| :-- |\n| 1/1/2021 3:00 |\n| https://c.example/ |\n" +
+			"| Note: by hand |\n| just text |\n| Comments: https://c.example/comments |\n| added: soon |\n"},
+		{"escaped pipes", "| A \\| B |\n| :-- |\n| 1/1/2021 3:00 |\n| https://a.example/?q=a\\|b |\n| x \\| y |\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			doc := ParseDocument("list.md", []byte(test.data))
+			if got := doc.String(); got != test.data {
+				t.Errorf("loaded:\ngot  %q\nwant %q", got, test.data)
+			}
+			doc.SetBytemarks(doc.Bytemarks())
+			if got := doc.String(); got != test.data {
+				t.Errorf("bytemarks set again:\ngot  %q\nwant %q", got, test.data)
+			}
+		})
+	}
+}
+
+func TestDocumentEdits(t *testing.T) {
+	const prose = "# Reading\n\nSome prose.\n\n"
+	tableC := "| C |\n| :-- |\n| 3/1/2021 9:30 |\n| https://c.example/ |\n"
+	data := prose + tableA + "\n\n" + tableB + "\n## Done\n"
+
+	tests := []struct {
+		name string
+		edit func([]Bytemark) []Bytemark
+		want string
+	}{
+		{
+			name: "tag",
+			edit: func(bytemarks []Bytemark) []Bytemark {
+				bytemarks[1].AddTags("go")
+				return bytemarks
+			},
+			want: prose + tableA + "\n\n" + tableB + "| tags: go |\n\n## Done\n",
+		},
+		{
+			name: "change a labeled row",
+			edit: func(bytemarks []Bytemark) []Bytemark {
+				bytemarks[1].CommentsURL = "https://news.ycombinator.com/item?id=3"
+				return bytemarks
+			},
+			want: prose + tableA + "\n\n" + strings.Replace(tableB, "id=2", "id=3", 1) + "\n## Done\n",
+		},
+		{
+			name: "remove",
+			edit: func(bytemarks []Bytemark) []Bytemark {
+				return bytemarks[1:]
+			},
+			want: prose + tableB + "\n## Done\n",
+		},
+		{
+			name: "add",
+			edit: func(bytemarks []Bytemark) []Bytemark {
+				c, err := ParseBytemark(tableC)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return append(bytemarks, c)
+			},
+			want: prose + tableA + "\n\n" + tableB + "\n" + tableC + "\n## Done\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			doc := ParseDocument("list.md", []byte(data))
+			doc.SetBytemarks(test.edit(doc.Bytemarks()))
+			if got := doc.String(); got != test.want {
+				t.Errorf("got\n%s\nwant\n%s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDocumentAddToEmpty(t *testing.T) {
+	a, err := ParseBytemark(tableA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := ParseDocument("list.md", nil)
+	doc.SetBytemarks([]Bytemark{a, a})
+	if got, want := doc.String(), tableA+"\n"+tableA+"\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,554 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// What is done with a new bytemark that is already saved.
+type DuplicatePolicy string
+
+const (
+	DuplicateSkip  DuplicatePolicy = "skip"  // Leave it out.
+	DuplicateWarn  DuplicatePolicy = "warn"  // Save it anyway, and say so.
+	DuplicateMerge DuplicatePolicy = "merge" // Merge it into the saved one.
+)
+
+// Set from the config or --duplicates.
+var Duplicates = DuplicateWarn
+
+func ParseDuplicatePolicy(s string) (DuplicatePolicy, error) {
+	switch policy := DuplicatePolicy(strings.ToLower(s)); policy {
+	case DuplicateSkip, DuplicateWarn, DuplicateMerge:
+		return policy, nil
+	}
+	return "", fmt.Errorf("Unknown duplicate policy '%s': use skip, warn or merge.", s)
+}
+
+// The canonical URL of b, used to tell whether two bytemarks point to
+// the same page.
+func (b Bytemark) URLKey() string {
+	if canonical, err := CanonicalURL(b.RootURL); err == nil {
+		return canonical
+	}
+	return b.RootURL
+}
+
+// The title without case, punctuation or spacing.
+func normalizeTitle(title string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// Titles shorter than this are too generic to match on, eg: "Home".
+const minTitleLength = 12
+
+// Whether two normalized titles differ by at most a tenth of their
+// length, eg: a typo or a trailing " (2021)".
+func similarTitles(a, b string) bool {
+	if len(a) < minTitleLength || len(b) < minTitleLength {
+		return false
+	}
+	if a == b {
+		return true
+	}
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	maxEdits := longest / 10
+	if diff := len(ra) - len(rb); diff > maxEdits || -diff > maxEdits {
+		return false
+	}
+	return editDistance(ra, rb) <= maxEdits
+}
+
+// Levenshtein distance.
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, minInt(curr[j-1]+1, prev[j-1]+cost))
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Whether a and b are the same page: the same canonical URL. Titles are
+// only compared for the dedupe report (see FindDuplicates), since
+// different pages can have nearly the same title, eg: Rust 1.70 released
+// and Rust 1.71 released.
+func IsDuplicate(a, b Bytemark) bool {
+	return a.URLKey() == b.URLKey()
+}
+
+var readStateRank = map[ReadState]int{
+	"":         0,
+	Unread:     1,
+	InProgress: 2,
+	Read:       3,
+}
+
+// Merge others into b. Tags, notes, rows and metadata are combined; the
+// fields b already has are kept. The bytemark ends up as read as the
+// most read of them, and as old as the oldest.
+func MergeBytemarks(b Bytemark, others ...Bytemark) Bytemark {
+	for _, other := range others {
+		if b.Title == "" {
+			b.Title = other.Title
+		}
+		if b.CommentsURL == "" {
+			b.CommentsURL = other.CommentsURL
+		}
+		if b.OriginalURL == "" {
+			b.OriginalURL = other.OriginalURL
+		}
+		if b.Source == "" {
+			b.Source = other.Source
+		}
+		b.AddTags(other.Tags...)
+		if other.Notes != "" && !strings.Contains(b.Notes, other.Notes) {
+			if b.Notes != "" {
+				b.Notes += " / "
+			}
+			b.Notes += other.Notes
+		}
+		if readStateRank[other.ReadState] > readStateRank[b.ReadState] {
+			b.ReadState = other.ReadState
+			b.ReadAt = other.ReadAt
+		}
+		if !other.Added.IsZero() && (b.Added.IsZero() || other.Added.Before(b.Added)) {
+			b.Added = other.Added
+			b.DateTime = other.DateTime
+		}
+		b.Page = mergePageMeta(b.Page, other.Page)
+		for _, row := range other.Rows {
+			if !containsString(b.Rows, row) {
+				b.Rows = append(b.Rows, row)
+			}
+		}
+	}
+	b.Touch()
+	return b
+}
+
+func mergePageMeta(p, other PageMeta) PageMeta {
+	fields := []struct {
+		into *string
+		from string
+	}{
+		{&p.Description, other.Description},
+		{&p.Author, other.Author},
+		{&p.Published, other.Published},
+		{&p.Canonical, other.Canonical},
+		{&p.SiteName, other.SiteName},
+		{&p.Lang, other.Lang},
+		{&p.Favicon, other.Favicon},
+		{&p.Image, other.Image},
+	}
+	for _, field := range fields {
+		if *field.into == "" {
+			*field.into = field.from
+		}
+	}
+	return p
+}
+
+// The collections of every hyperpath whose files exist.
+func LoadAllCollections() ([]*Collection, error) {
+	hyperpaths, err := GetHyperpaths()
+	if err != nil {
+		return nil, err
+	}
+	collections := make([]*Collection, 0, len(hyperpaths))
+	for _, hp := range hyperpaths {
+		if !hp.Exists() {
+			continue
+		}
+		collection, err := LoadCollection(hp)
+		if err != nil {
+			return nil, err
+		}
+		collections = append(collections, collection)
+	}
+	return collections, nil
+}
+
+// Reports a problem that did not stop the work, eg: a hyperpath that
+// could not be read. Replaced by the TUI, which has no stderr to print to.
+var Warn = func(message string) {
+	fmt.Fprintf(os.Stderr, "warning: %s\n", message)
+}
+
+// Like LoadAllCollections, but the hyperpaths that cannot be loaded are
+// skipped with a warning, so that one broken hyperpath does not stop
+// bytemarks from being saved to the others.
+func loadReadableCollections() ([]*Collection, error) {
+	hyperpaths, err := GetHyperpaths()
+	if err != nil {
+		return nil, err
+	}
+	collections := make([]*Collection, 0, len(hyperpaths))
+	for _, hp := range hyperpaths {
+		if !hp.Exists() {
+			continue
+		}
+		collection, err := LoadCollection(hp)
+		if err != nil {
+			Warn(fmt.Sprintf("Skipped hyperpath %s: %v", hp.Name, err))
+			continue
+		}
+		collections = append(collections, collection)
+	}
+	return collections, nil
+}
+
+// The bytemarks of collections. A file in more than one of them is only
+// counted once.
+func AllBytemarks(collections []*Collection) []Bytemark {
+	bytemarks := make([]Bytemark, 0)
+	for _, location := range locate(collections) {
+		bytemarks = append(bytemarks, location.Bytemark)
+	}
+	return bytemarks
+}
+
+// Where a saved bytemark is.
+type Location struct {
+	Collection *Collection
+	Index      int // In Collection.Bytemarks().
+	Bytemark   Bytemark
+}
+
+func (l Location) String() string {
+	return fmt.Sprintf("%s: %s %s",
+		l.Collection.Hyperpath.Name, l.Bytemark.Title, l.Bytemark.RootURL)
+}
+
+// Bytemarks that are the same page, in the order of the hyperpaths.
+type DuplicateGroup []Location
+
+// Every bytemark of collections. A file in more than one hyperpath, eg: a
+// directory and one of its files, is only looked at once, so that its
+// bytemarks are not duplicates of themselves.
+func locate(collections []*Collection) []Location {
+	locations := make([]Location, 0)
+	seen := make(map[string]*Collection)
+	for _, collection := range collections {
+		for i, bytemark := range collection.Bytemarks() {
+			if c, ok := seen[bytemark.File()]; ok && c != collection {
+				continue
+			}
+			seen[bytemark.File()] = collection
+			locations = append(locations, Location{collection, i, bytemark})
+		}
+	}
+	return locations
+}
+
+// Group the bytemarks of collections that are duplicates of each other.
+func FindDuplicates(collections []*Collection) []DuplicateGroup {
+	locations := locate(collections)
+
+	// Union-find over the locations.
+	parent := make([]int, len(locations))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	union := func(a, b int) {
+		if ra, rb := find(a), find(b); ra != rb {
+			if ra < rb {
+				parent[rb] = ra
+			} else {
+				parent[ra] = rb
+			}
+		}
+	}
+
+	byURL := make(map[string]int)
+	titles := make([]string, len(locations))
+	for i, location := range locations {
+		key := location.Bytemark.URLKey()
+		if first, ok := byURL[key]; ok {
+			union(first, i)
+		} else {
+			byURL[key] = i
+		}
+		titles[i] = normalizeTitle(location.Bytemark.Title)
+	}
+	for i := range locations {
+		for j := i + 1; j < len(locations); j++ {
+			if similarTitles(titles[i], titles[j]) {
+				union(i, j)
+			}
+		}
+	}
+
+	byRoot := make(map[int]DuplicateGroup)
+	for i, location := range locations {
+		root := find(i)
+		byRoot[root] = append(byRoot[root], location)
+	}
+	roots := make([]int, 0)
+	for root, group := range byRoot {
+		if len(group) > 1 {
+			roots = append(roots, root)
+		}
+	}
+	sort.Ints(roots)
+	groups := make([]DuplicateGroup, 0, len(roots))
+	for _, root := range roots {
+		groups = append(groups, byRoot[root])
+	}
+	return groups
+}
+
+// Merge every group into its first bytemark, remove the others and save
+// the collections that changed as one change, see SaveCollections.
+func MergeDuplicates(groups []DuplicateGroup) error {
+	return SaveCollections(mergeDuplicates(groups)...)
+}
+
+// The changed collections are returned, to be saved.
+func mergeDuplicates(groups []DuplicateGroup) []*Collection {
+	merged := make(map[*Collection]map[int]Bytemark)
+	removed := make(map[*Collection][]int)
+	changed := make([]*Collection, 0)
+	touch := func(c *Collection) {
+		if _, ok := merged[c]; !ok {
+			merged[c] = make(map[int]Bytemark)
+			changed = append(changed, c)
+		}
+	}
+
+	for _, group := range groups {
+		first := group[0]
+		others := make([]Bytemark, 0, len(group)-1)
+		for _, location := range group[1:] {
+			others = append(others, location.Bytemark)
+			touch(location.Collection)
+			removed[location.Collection] = append(removed[location.Collection], location.Index)
+		}
+		touch(first.Collection)
+		merged[first.Collection][first.Index] = MergeBytemarks(first.Bytemark, others...)
+	}
+
+	for _, collection := range changed {
+		bytemarks := make([]Bytemark, 0)
+		for i, bytemark := range collection.Bytemarks() {
+			if contains(removed[collection], i) {
+				continue
+			}
+			if b, ok := merged[collection][i]; ok {
+				bytemark = b
+			}
+			bytemarks = append(bytemarks, bytemark)
+		}
+		collection.SetBytemarks(bytemarks)
+	}
+	return changed
+}
+
+// A new bytemark that was already saved.
+type Duplicate struct {
+	Bytemark Bytemark // The new one.
+	Existing Location
+	Action   DuplicatePolicy // What was done with the new one.
+}
+
+func (d Duplicate) String() string {
+	where := "another new bytemark"
+	if d.Existing.Collection != nil {
+		where = d.Existing.Collection.Hyperpath.Name
+	}
+	var done string
+	switch d.Action {
+	case DuplicateSkip:
+		done = "skipped"
+	case DuplicateWarn:
+		done = "saved anyway"
+	case DuplicateMerge:
+		done = "merged"
+	}
+	return fmt.Sprintf("%s is already in %s (%s)",
+		d.Bytemark.RootURL, where, done)
+}
+
+// Check bytemarks against the ones saved in the hyperpaths and in file,
+// and deal with the duplicates according to Duplicates. Returns the
+// bytemarks to write. Hyperpaths that cannot be loaded are skipped.
+func CheckDuplicates(bytemarks []Bytemark, file string) ([]Bytemark, []Duplicate, error) {
+	collections, err := loadReadableCollections()
+	if err != nil {
+		return nil, nil, err
+	}
+	if file != "" && PathExists(file) && !collectionsHaveFile(collections, file) {
+		collection, err := LoadCollection(Hyperpath{Name: file, Path: file})
+		if err != nil {
+			return nil, nil, err
+		}
+		collections = append(collections, collection)
+	}
+
+	// The bytemarks of a file that is about to be overwritten are not
+	// kept, so they do not count.
+	var replaced string
+	if pendingOverwrites[file] {
+		replaced = AbsPath(file)
+	}
+	saved := make([]Location, 0)
+	for _, collection := range collections {
+		for i, bytemark := range collection.Bytemarks() {
+			if replaced != "" && AbsPath(bytemark.file) == replaced {
+				continue
+			}
+			saved = append(saved, Location{collection, i, bytemark})
+		}
+	}
+
+	kept := make([]Bytemark, 0, len(bytemarks))
+	duplicates := make([]Duplicate, 0)
+	merges := make([]DuplicateGroup, 0)
+	for _, bytemark := range bytemarks {
+		if j, found := findNewDuplicate(kept, bytemark); found {
+			existing := Location{Index: j, Bytemark: kept[j]}
+			duplicates = append(duplicates, Duplicate{bytemark, existing, Duplicates})
+			switch Duplicates {
+			case DuplicateWarn:
+				kept = append(kept, bytemark)
+			case DuplicateMerge:
+				kept[j] = MergeBytemarks(kept[j], bytemark)
+			}
+			continue
+		}
+
+		existing, found := findDuplicate(saved, bytemark)
+		if !found {
+			kept = append(kept, bytemark)
+			continue
+		}
+		duplicates = append(duplicates, Duplicate{bytemark, existing, Duplicates})
+		switch Duplicates {
+		case DuplicateWarn:
+			kept = append(kept, bytemark)
+		case DuplicateMerge:
+			// The new bytemark is merged as if it were saved after it.
+			merges = append(merges, DuplicateGroup{existing, {Bytemark: bytemark}})
+		}
+	}
+
+	if err := SaveCollections(mergeNew(merges)...); err != nil {
+		return nil, nil, err
+	}
+	return kept, duplicates, nil
+}
+
+func collectionsHaveFile(collections []*Collection, file string) bool {
+	for _, collection := range collections {
+		if collection.document(file) != nil || collection.DefaultFile == file {
+			return true
+		}
+	}
+	return false
+}
+
+func findDuplicate(saved []Location, bytemark Bytemark) (Location, bool) {
+	for _, location := range saved {
+		if IsDuplicate(location.Bytemark, bytemark) {
+			return location, true
+		}
+	}
+	return Location{}, false
+}
+
+func findNewDuplicate(bytemarks []Bytemark, bytemark Bytemark) (int, bool) {
+	for i, b := range bytemarks {
+		if IsDuplicate(b, bytemark) {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// mergeDuplicates for groups of a saved bytemark and new ones, which have
+// no collection to be removed from.
+func mergeNew(groups []DuplicateGroup) []*Collection {
+	combined := make(map[*Collection]map[int]DuplicateGroup)
+	for _, group := range groups {
+		first := group[0]
+		if combined[first.Collection] == nil {
+			combined[first.Collection] = make(map[int]DuplicateGroup)
+		}
+		existing, ok := combined[first.Collection][first.Index]
+		if !ok {
+			existing = DuplicateGroup{first}
+		}
+		combined[first.Collection][first.Index] = append(existing, group[1:]...)
+	}
+
+	changed := make([]*Collection, 0)
+	for collection, byIndex := range combined {
+		bytemarks := collection.Bytemarks()
+		for i, group := range byIndex {
+			others := make([]Bytemark, 0)
+			for _, location := range group[1:] {
+				others = append(others, location.Bytemark)
+			}
+			bytemarks[i] = MergeBytemarks(bytemarks[i], others...)
+		}
+		collection.SetBytemarks(bytemarks)
+		changed = append(changed, collection)
+	}
+	return changed
+}
+
+// Write bytemarks to outputPath with Write, after dealing with the ones
+// that are already saved (see CheckDuplicates). Nothing is checked when
+// writing to stdout or the clipboard.
+func WriteBytemarks(
+	outputPath *os.File,
+	bytemarks []Bytemark,
+	clipboardOut bool,
+) (writtenTo string, written int, duplicates []Duplicate, err error) {
+	if !clipboardOut && outputPath != os.Stdout {
+		bytemarks, duplicates, err = CheckDuplicates(bytemarks, outputPath.Name())
+		if err != nil {
+			return outputPath.Name(), 0, duplicates, err
+		}
+	}
+	writtenTo, err = Write(outputPath, BytemarksToTables(bytemarks), clipboardOut)
+	return writtenTo, len(bytemarks), duplicates, err
+}
@@ -0,0 +1,166 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+const EDITOR_ENV = "EDITOR"
+
+// The fields of a bytemark that are edited by hand, as text.
+type BytemarkEdit struct {
+	Title string
+	URL   string
+	Tags  string // Separated by commas.
+	Notes string
+	Rows  []string // The other rows, eg: author: someone.
+}
+
+// The fields of b as they are edited, see ApplyEdit.
+func (b Bytemark) Editable() BytemarkEdit {
+	return BytemarkEdit{
+		Title: b.Title,
+		URL:   b.RootURL,
+		Tags:  strings.Join(b.Tags, ", "),
+		Notes: b.Notes,
+		Rows:  append([]string(nil), b.Rows...),
+	}
+}
+
+// An error if raw is not an absolute URL, eg: https://example.com/ or
+// mailto:me@example.com.
+func ValidateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || raw == "" || strings.ContainsAny(raw, " \t\n") {
+		return fmt.Errorf("Invalid URL: %s.", raw)
+	}
+	switch {
+	case u.Scheme == "":
+		return fmt.Errorf("Invalid URL, it has no scheme: %s.", raw)
+	case u.Host == "" && u.Opaque == "":
+		return fmt.Errorf("Invalid URL, it has no host: %s.", raw)
+	case (u.Scheme == "http" || u.Scheme == "https") && u.Host == "":
+		return fmt.Errorf("Invalid URL, it has no host: %s.", raw)
+	}
+	return nil
+}
+
+// Set the fields of b from e, or return why they cannot be. A new URL is
+// canonicalized like the URL of a new bytemark. b is touched if anything
+// changed.
+func (b *Bytemark) ApplyEdit(e BytemarkEdit) error {
+	edited := *b
+	edited.Title = strings.TrimSpace(e.Title)
+	if edited.Title == "" {
+		return errors.New("The title is empty.")
+	}
+	if rawURL := strings.TrimSpace(e.URL); rawURL != b.RootURL {
+		if err := ValidateURL(rawURL); err != nil {
+			return err
+		}
+		edited.RootURL = rawURL
+		edited.OriginalURL = ""
+		if err := edited.SetCanonicalURL(rawURL); err != nil {
+			return err
+		}
+	}
+	edited.Tags = nil
+	edited.AddTags(splitTags(e.Tags)...)
+	edited.Notes = strings.TrimSpace(e.Notes)
+
+	edited.Rows = nil
+	for _, row := range e.Rows {
+		if row = strings.TrimSpace(row); row == "" {
+			continue
+		}
+		if _, ok := edited.setRow(row); !ok {
+			edited.Rows = append(edited.Rows, row)
+		}
+	}
+
+	if edited.Table() != b.Table() {
+		edited.Touch()
+		*b = edited
+	}
+	return nil
+}
+
+// Read text as a single bytemark table, eg: one edited by hand.
+func ParseBytemark(text string) (Bytemark, error) {
+	text = strings.Trim(text, "\n")
+	if strings.Contains(text, "\n\n") {
+		return Bytemark{}, errors.New("Expected one bytemark table.")
+	}
+	if !isBytemarkTable(text) {
+		return Bytemark{}, errors.New("Not a bytemark table.")
+	}
+	bytemark, diagnostics := parseTable("", text, 1)
+	if len(diagnostics) != 0 {
+		d := diagnostics[0]
+		return Bytemark{}, fmt.Errorf("Line %d, column %d: %s.", d.Line, d.Column, d.Reason)
+	}
+	return bytemark, nil
+}
+
+// The command that edits path: $EDITOR if it is set, otherwise vi.
+func EditorCommand(path string) *exec.Cmd {
+	args := strings.Fields(os.Getenv(EDITOR_ENV))
+	if len(args) == 0 {
+		args = []string{"vi"}
+	}
+	cmd := exec.Command(args[0], append(args[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd
+}
+
+// Edits text in the user's editor, returning what it was saved as.
+// Replaced in tests so that no editor is started.
+var EditText = editText
+
+func editText(text string) (string, error) {
+	tmp, err := ioutil.TempFile("", "hypermark-*.md")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(text); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+
+	if err := EditorCommand(tmp.Name()).Run(); err != nil {
+		return "", fmt.Errorf("Editor failed: %v.", err)
+	}
+	edited, err := ioutil.ReadFile(tmp.Name())
+	return string(edited), err
+}
+
+// Edit b as a table in the user's editor. The file b belongs to is kept,
+// and b is touched if it changed.
+func (b *Bytemark) EditInEditor() error {
+	text, err := EditText(b.Table())
+	if err != nil {
+		return err
+	}
+	edited, err := ParseBytemark(text)
+	if err != nil {
+		return err
+	}
+	if edited.Table() == b.Table() {
+		return nil
+	}
+	edited.file = b.file
+	edited.Touch()
+	*b = edited
+	return nil
+}
@@ -0,0 +1,294 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A hyperpath and the name it is addressed by, eg: reading. Names stay
+// the same when hyperpaths are reordered, unlike their indices.
+// Path is a markdown file, a directory of them or a glob such as
+// ~/notes/reading/*.md. For the last two, new bytemarks are written to
+// Default, which is relative to the directory of the hyperpath.
+type Hyperpath struct {
+	Name    string `toml:"name"`
+	Path    string `toml:"path"`
+	Default string `toml:"default,omitempty"`
+}
+
+func isGlob(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// Whether the hyperpath can hold more than one file.
+func (hp Hyperpath) IsMulti() bool {
+	path := ExpandTilde(hp.Path)
+	return isGlob(path) || (PathExists(path) && isDirectory(path))
+}
+
+// The directory of a directory or glob hyperpath.
+func (hp Hyperpath) dir() string {
+	path := ExpandTilde(hp.Path)
+	if !isGlob(path) && PathExists(path) && isDirectory(path) {
+		return path
+	}
+	dir := filepath.Dir(path)
+	for isGlob(dir) {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
+
+// The markdown files of the hyperpath, sorted.
+func (hp Hyperpath) Files() ([]string, error) {
+	path := ExpandTilde(hp.Path)
+	if !hp.IsMulti() {
+		return []string{path}, nil
+	}
+
+	pattern := path
+	if !isGlob(path) {
+		pattern = filepath.Join(path, "*.md")
+	}
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(matches))
+	for _, match := range matches {
+		if !isDirectory(match) {
+			files = append(files, match)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Whether file is, or could be, one of the files of the hyperpath. Unlike
+// Files, this holds for files that were deleted since.
+func (hp Hyperpath) Has(file string) bool {
+	path := ExpandTilde(hp.Path)
+	if !hp.IsMulti() {
+		return samePath(path, file)
+	}
+	if defaultFile, err := hp.DefaultFile(); err == nil && samePath(defaultFile, file) {
+		return true
+	}
+	pattern := path
+	if !isGlob(path) {
+		pattern = filepath.Join(path, "*.md")
+	}
+	if abs, err := filepath.Abs(pattern); err == nil {
+		pattern = abs
+	}
+	matched, _ := filepath.Match(pattern, file)
+	return matched
+}
+
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
+
+// The file new bytemarks are written to.
+func (hp Hyperpath) DefaultFile() (string, error) {
+	if !hp.IsMulti() {
+		return ExpandTilde(hp.Path), nil
+	}
+	if hp.Default != "" {
+		path := ExpandTilde(hp.Default)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(hp.dir(), path)
+		}
+		return path, nil
+	}
+	files, err := hp.Files()
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf(
+			"hyperpath[%s] has no files and no default file to write to.",
+			hp.Name,
+		)
+	}
+	return files[0], nil
+}
+
+// Whether the file, directory or the directory of the glob exists.
+func (hp Hyperpath) Exists() bool {
+	if isGlob(ExpandTilde(hp.Path)) {
+		return PathExists(hp.dir())
+	}
+	return PathExists(ExpandTilde(hp.Path))
+}
+
+var (
+	hyperpathNameRegex = regexp.MustCompile(`^[A-Za-z][\w.-]*$`)
+	nameCharsRegex     = regexp.MustCompile(`[^\w.-]+`)
+)
+
+func ValidHyperpathName(name string) error {
+	if !hyperpathNameRegex.MatchString(name) {
+		return fmt.Errorf(
+			"Invalid hyperpath name '%s': use letters, digits, '.', '_' or '-', starting with a letter.",
+			name,
+		)
+	}
+	return nil
+}
+
+func nameTaken(hyperpaths []Hyperpath, name string) bool {
+	for _, hp := range hyperpaths {
+		if hp.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// A name for path that is not taken by any of hyperpaths, based on the
+// name of the file, eg: ~/notes/Reading List.md -> reading-list
+func NewHyperpathName(hyperpaths []Hyperpath, path string) string {
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	base = strings.Trim(nameCharsRegex.ReplaceAllString(strings.ToLower(base), "-"), "-")
+	if ValidHyperpathName(base) != nil {
+		base = "hyperpath" + base
+	}
+
+	name := base
+	for i := 2; nameTaken(hyperpaths, name); i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+	return name
+}
+
+// Give every unnamed hyperpath a name. Returns whether any were named.
+func nameHyperpaths(hyperpaths []Hyperpath) bool {
+	named := false
+	for i := range hyperpaths {
+		if hyperpaths[i].Name == "" {
+			hyperpaths[i].Name = NewHyperpathName(hyperpaths, hyperpaths[i].Path)
+			named = true
+		}
+	}
+	return named
+}
+
+func GetHyperpaths() ([]Hyperpath, error) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return make([]Hyperpath, 0), err
+	}
+	return cfg.Hyperpaths, nil
+}
+
+// Find a hyperpath by name. An index, eg: 0, is accepted as well.
+func ResolveHyperpath(selector string) (Hyperpath, int, error) {
+	hyperpaths, err := GetHyperpaths()
+	if err != nil {
+		return Hyperpath{}, -1, err
+	}
+	for i, hp := range hyperpaths {
+		if hp.Name == selector {
+			return hp, i, nil
+		}
+	}
+	if n, err := strconv.Atoi(selector); err == nil && n >= 0 && n < len(hyperpaths) {
+		return hyperpaths[n], n, nil
+	}
+	return Hyperpath{}, -1, fmt.Errorf("No hyperpath named '%s'.", selector)
+}
+
+// The hyperpath set as the archive in the config.
+func ArchiveHyperpath() (Hyperpath, error) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return Hyperpath{}, err
+	}
+	if cfg.Defaults.Archive == "" {
+		return Hyperpath{}, errors.New(
+			"No archive hyperpath: set archive in the [defaults] of the config.")
+	}
+	hp, _, err := ResolveHyperpath(cfg.Defaults.Archive)
+	return hp, err
+}
+
+func RenameHyperpath(n int, name string) error {
+	if err := ValidHyperpathName(name); err != nil {
+		return err
+	}
+	hyperpaths, err := GetHyperpaths()
+	if err != nil {
+		return err
+	}
+	if n < 0 || n >= len(hyperpaths) {
+		return fmt.Errorf("Cannot rename hyperpath[%d]: Out of range", n)
+	}
+	if hyperpaths[n].Name == name {
+		return nil
+	}
+	if nameTaken(hyperpaths, name) {
+		return fmt.Errorf("There is already a hyperpath named '%s'.", name)
+	}
+	hyperpaths[n].Name = name
+	return WriteHyperpaths(hyperpaths)
+}
+
+// Add a hyperpath to the end of the list. A name is made up from the
+// path if name is empty.
+func AddHyperpath(name, path string) (Hyperpath, error) {
+	hyperpaths, err := GetHyperpaths()
+	if err != nil {
+		return Hyperpath{}, err
+	}
+	hp := Hyperpath{Name: name, Path: AbsPath(path)}
+	if !hp.Exists() {
+		return Hyperpath{}, fmt.Errorf("Invalid file path: %s", path)
+	}
+	if name == "" {
+		hp.Name = NewHyperpathName(hyperpaths, path)
+	} else if err := ValidHyperpathName(name); err != nil {
+		return Hyperpath{}, err
+	} else if nameTaken(hyperpaths, name) {
+		return Hyperpath{}, fmt.Errorf("There is already a hyperpath named '%s'.", name)
+	}
+	return hp, WriteHyperpaths(append(hyperpaths, hp))
+}
+
+// Remove the hyperpath named by selector (see ResolveHyperpath). Its
+// files are left alone.
+func RemoveHyperpath(selector string) (Hyperpath, error) {
+	hp, n, err := ResolveHyperpath(selector)
+	if err != nil {
+		return Hyperpath{}, err
+	}
+	hyperpaths, err := GetHyperpaths()
+	if err != nil {
+		return Hyperpath{}, err
+	}
+	return hp, WriteHyperpaths(DeleteHyperpath(hyperpaths, n))
+}
+
+func DeleteHyperpath(original []Hyperpath, index int) []Hyperpath {
+	deleted := make([]Hyperpath, 0)
+	for i, element := range original {
+		if i != index {
+			deleted = append(deleted, element)
+		}
+	}
+	return deleted
+}
+
+func SwapHyperpaths(original []Hyperpath, indexA, indexB int) []Hyperpath {
+	swapped := make([]Hyperpath, len(original))
+	copy(swapped, original)
+	swapped[indexA], swapped[indexB] = original[indexB], original[indexA]
+	return swapped
+}
@@ -0,0 +1,351 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	JOURNAL_FILE       = "journal.jsonl"
+	SNAPSHOTS_DIR      = "snapshots"
+	DefaultJournalSize = 50
+)
+
+// How many saves the journal keeps. 0 turns the journal off.
+var JournalSize = DefaultJournalSize
+
+// A save recorded in the journal: what each file it wrote held before,
+// so that it can be undone. The contents themselves are kept in the
+// snapshots directory, one file per version, so the journal stays small.
+type JournalEntry struct {
+	Time  time.Time     `json:"time"`
+	Files []JournalFile `json:"files"`
+}
+
+type JournalFile struct {
+	Path   string `json:"path"`
+	Before string `json:"before"` // sha256 of the snapshot, "" if the file did not exist.
+	After  string `json:"after"`  // sha256 of what was written.
+}
+
+// The journal is kept next to the config file.
+func JournalPath() (string, error) {
+	config, err := ConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(config), JOURNAL_FILE), nil
+}
+
+// Snapshots are named after the sha256 of their contents, so a version
+// that several saves go back to is only kept once.
+func snapshotPath(journal, hash string) string {
+	return filepath.Join(snapshotsDir(journal), hash)
+}
+
+func snapshotsDir(journal string) string {
+	return filepath.Join(filepath.Dir(journal), SNAPSHOTS_DIR)
+}
+
+func hashString(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func readJournal(path string) ([]JournalEntry, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	entries := make([]JournalEntry, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var entry JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// Rewrite the journal with the last JournalSize entries, and delete the
+// snapshots that none of them go back to.
+func writeJournal(path string, entries []JournalEntry) error {
+	if len(entries) > JournalSize {
+		entries = entries[len(entries)-JournalSize:]
+	}
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		buf.Write(append(line, '\n'))
+	}
+	// Not WriteFileAtomic, the journal has no use for a backup.
+	if err := replaceFile(path, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	return pruneSnapshots(path, entries)
+}
+
+func pruneSnapshots(path string, entries []JournalEntry) error {
+	used := make(map[string]bool)
+	for _, entry := range entries {
+		for _, file := range entry.Files {
+			used[file.Before] = true
+		}
+	}
+	snapshots, err := ioutil.ReadDir(snapshotsDir(path))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, snapshot := range snapshots {
+		if !used[snapshot.Name()] {
+			if err := os.Remove(snapshotPath(path, snapshot.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Take the lock on the journal. It is taken before the lock on any of the
+// files a save writes, and held until they are written, so that a save
+// and an undo never wait on each other.
+func lockJournal() (*FileLock, error) {
+	path, err := JournalPath()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	return LockFile(path)
+}
+
+// Record in the journal that the files in writes are about to be given
+// new contents. It is written before the files are, so a save that fails
+// half way can still be undone. The journal must be locked, see
+// lockJournal.
+func journalWrites(writes map[string][]byte) error {
+	if JournalSize <= 0 || len(writes) == 0 {
+		return nil
+	}
+	path, err := JournalPath()
+	if err != nil {
+		return err
+	}
+
+	entry := JournalEntry{Time: time.Now().Truncate(time.Second)}
+	for file, data := range writes {
+		if abs, err := filepath.Abs(file); err == nil {
+			file = abs
+		}
+		journalFile := JournalFile{Path: file, After: hashString(data)}
+		before, err := ioutil.ReadFile(file)
+		if err == nil {
+			journalFile.Before = hashString(before)
+			if err := writeSnapshot(path, journalFile.Before, before); err != nil {
+				return err
+			}
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		entry.Files = append(entry.Files, journalFile)
+	}
+
+	entries, err := readJournal(path)
+	if err != nil {
+		return err
+	}
+	if len(entries) >= JournalSize {
+		return writeJournal(path, append(entries, entry))
+	}
+	return appendJournal(path, entry)
+}
+
+func writeSnapshot(journal, hash string, data []byte) error {
+	snapshot := snapshotPath(journal, hash)
+	if PathExists(snapshot) {
+		return nil
+	}
+	if err := os.MkdirAll(snapshotsDir(journal), 0755); err != nil {
+		return err
+	}
+	return replaceFile(snapshot, data, 0600)
+}
+
+func appendJournal(path string, entry JournalEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Record that output is about to be appended to file.
+func journalAppend(file, output string) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return journalWrites(map[string][]byte{file: append(data, output...)})
+}
+
+// Whether the entry wrote to any of the files of hp.
+func (e JournalEntry) touches(hp Hyperpath) bool {
+	for _, file := range e.Files {
+		if hp.Has(file.Path) {
+			return true
+		}
+	}
+	return false
+}
+
+// The saves to the files of hp that are in the journal, newest first.
+func JournalEntries(hp Hyperpath) ([]JournalEntry, error) {
+	path, err := JournalPath()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := readJournal(path)
+	if err != nil {
+		return nil, err
+	}
+
+	saves := make([]JournalEntry, 0)
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].touches(hp) {
+			saves = append(saves, entries[i])
+		}
+	}
+	return saves, nil
+}
+
+// A file of an entry was changed after the save that is being undone.
+var ErrChangedSince = errors.New("changed since it was saved")
+
+// How undoing the entry would change file: restore is false if the file
+// is already as it was before the save, eg: the save failed.
+func (f JournalFile) undoable() (restore bool, err error) {
+	current, err := ioutil.ReadFile(f.Path)
+	if os.IsNotExist(err) {
+		if f.Before == "" {
+			return false, nil
+		}
+		return false, fmt.Errorf("%s: %w", f.Path, ErrChangedSince)
+	} else if err != nil {
+		return false, err
+	}
+
+	if hashString(current) == f.After {
+		return true, nil
+	}
+	if f.Before != "" && hashString(current) == f.Before {
+		return false, nil
+	}
+	return false, fmt.Errorf("%s: %w", f.Path, ErrChangedSince)
+}
+
+// Undo the last steps saves to the files of hp, newest first, and drop
+// them from the journal. Stops at a save whose files were changed since,
+// returning the saves that were undone and ErrChangedSince.
+func Undo(hp Hyperpath, steps int) ([]JournalEntry, error) {
+	path, err := JournalPath()
+	if err != nil {
+		return nil, err
+	}
+	lock, err := lockJournal()
+	if err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	entries, err := readJournal(path)
+	if err != nil {
+		return nil, err
+	}
+
+	undone := make([]JournalEntry, 0)
+	for i := len(entries) - 1; i >= 0 && len(undone) < steps; i-- {
+		entry := entries[i]
+		if !entry.touches(hp) {
+			continue
+		}
+		var restored bool
+		if restored, err = undoEntry(path, entry); err != nil {
+			break
+		}
+		if restored {
+			undone = append(undone, entry)
+		}
+		entries = append(entries[:i], entries[i+1:]...)
+	}
+	if writeErr := writeJournal(path, entries); writeErr != nil {
+		return undone, writeErr
+	}
+	if err == nil && len(undone) == 0 {
+		err = fmt.Errorf("No saves to %s to undo.", hp.Name)
+	}
+	return undone, err
+}
+
+// Put the files of entry back the way they were, returning whether any
+// needed to be. Nothing is written if any of them was changed since.
+func undoEntry(journal string, entry JournalEntry) (bool, error) {
+	restore := make(map[string][]byte)
+	for _, file := range entry.Files {
+		changed, err := file.undoable()
+		if err != nil {
+			return false, err
+		}
+		if !changed {
+			continue
+		}
+		var data []byte // nil removes the file.
+		if file.Before != "" {
+			if data, err = ioutil.ReadFile(snapshotPath(journal, file.Before)); err != nil {
+				return false, err
+			}
+		}
+		restore[file.Path] = data
+	}
+	for path, data := range restore {
+		if err := restoreFile(path, data); err != nil {
+			return false, err
+		}
+	}
+	return len(restore) != 0, nil
+}
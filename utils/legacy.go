@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// eg: 0: /home/me/reading list.md
+var legacyLineRegex = regexp.MustCompile(`^(\d+):\s+(.*)$`)
+
+type legacyEntry struct {
+	index int
+	path  string
+}
+
+// Read a path that is either "quoted" or bare. Backslash escapes the
+// next character in both, eg: "a \"b\".md" or a\ b.md
+func unquoteLegacyPath(raw string) (string, error) {
+	quoted := strings.HasPrefix(raw, `"`)
+	if quoted {
+		raw = raw[1:]
+	}
+
+	var path strings.Builder
+	for i := 0; i < len(raw); i++ {
+		switch c := raw[i]; {
+		case c == '\\' && i+1 < len(raw):
+			i++
+			path.WriteByte(raw[i])
+		case c == '"' && quoted:
+			if rest := strings.TrimSpace(raw[i+1:]); rest != "" {
+				return "", fmt.Errorf("unexpected %q after closing quote", rest)
+			}
+			return path.String(), nil
+		default:
+			path.WriteByte(c)
+		}
+	}
+	if quoted {
+		return "", fmt.Errorf("missing closing quote")
+	}
+	return path.String(), nil
+}
+
+// Parse a legacy hyperpaths file of "N: path" lines. Blank lines and
+// lines starting with # are skipped. Hyperpaths are returned in the
+// order of their indices.
+func parseLegacyHyperpaths(data string) ([]string, error) {
+	entries := make([]legacyEntry, 0)
+	seen := make(map[int]int) // index -> line
+
+	for i, line := range strings.Split(data, "\n") {
+		lineNum := i + 1
+		line = strings.TrimSpace(strings.Trim(line, "\x00"))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		match := legacyLineRegex.FindStringSubmatch(line)
+		if match == nil {
+			return nil, fmt.Errorf("line %d: expected 'N: path', got %q", lineNum, line)
+		}
+		index, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNum, err)
+		}
+		if first, ok := seen[index]; ok {
+			return nil, fmt.Errorf(
+				"line %d: duplicate index %d, already used on line %d",
+				lineNum, index, first,
+			)
+		}
+		path, err := unquoteLegacyPath(match[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNum, err)
+		}
+		if path == "" {
+			return nil, fmt.Errorf("line %d: empty path", lineNum)
+		}
+
+		seen[index] = lineNum
+		entries = append(entries, legacyEntry{index: index, path: path})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].index < entries[j].index
+	})
+	hyperpaths := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		hyperpaths = append(hyperpaths, entry.path)
+	}
+	return hyperpaths, nil
+}
+
+func readLegacyHyperpaths(path string) ([]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	hyperpaths, err := parseLegacyHyperpaths(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return hyperpaths, nil
+}
@@ -0,0 +1,140 @@
+package utils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// A hyperpaths file of n entries, larger than one 1KB read.
+func longLegacyFile(n int) (string, []string) {
+	var data strings.Builder
+	want := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		path := fmt.Sprintf("/home/me/notes/reading list %03d.md", i)
+		fmt.Fprintf(&data, "%d: %s\n", i, path)
+		want = append(want, path)
+	}
+	return data.String(), want
+}
+
+func TestParseLegacyHyperpaths(t *testing.T) {
+	long, longWant := longLegacyFile(100)
+	if len(long) <= 1024 {
+		t.Fatalf("the long file is only %d bytes", len(long))
+	}
+
+	tests := []struct {
+		name string
+		data string
+		want []string
+		err  string
+	}{
+		{
+			name: "empty",
+			data: "",
+			want: []string{},
+		},
+		{
+			name: "in order",
+			data: "0: /a.md\n1: /b.md\n",
+			want: []string{"/a.md", "/b.md"},
+		},
+		{
+			name: "sorted by index",
+			data: "2: /c.md\n0: /a.md\n1: /b.md",
+			want: []string{"/a.md", "/b.md", "/c.md"},
+		},
+		{
+			name: "longer than 1KB",
+			data: long,
+			want: longWant,
+		},
+		{
+			name: "spaces",
+			data: "0: /home/me/reading list.md\n1:    /notes/to do.md  \n",
+			want: []string{"/home/me/reading list.md", "/notes/to do.md"},
+		},
+		{
+			name: "quoted",
+			data: `0: "/home/me/reading list.md"` + "\n" + `1: "/a \"b\".md"`,
+			want: []string{"/home/me/reading list.md", `/a "b".md`},
+		},
+		{
+			name: "escaped",
+			data: `0: /home/me/reading\ list.md` + "\n" + `1: /a\\b.md`,
+			want: []string{"/home/me/reading list.md", `/a\b.md`},
+		},
+		{
+			name: "comments and blank lines",
+			data: "# hyperpaths\n\n0: /a.md\n   \n# 1: /b.md\n1: /c.md\n\n",
+			want: []string{"/a.md", "/c.md"},
+		},
+		{
+			name: "windows line endings",
+			data: "0: /a.md\r\n1: /b.md\r\n",
+			want: []string{"/a.md", "/b.md"},
+		},
+		{
+			name: "duplicate index",
+			data: "0: /a.md\n1: /b.md\n0: /c.md\n",
+			err:  "line 3: duplicate index 0, already used on line 1",
+		},
+		{
+			name: "no index",
+			data: "/a.md\n",
+			err:  `line 1: expected 'N: path', got "/a.md"`,
+		},
+		{
+			name: "missing closing quote",
+			data: "0: \"/a.md\n",
+			err:  "line 1: missing closing quote",
+		},
+		{
+			name: "text after closing quote",
+			data: "0: \"/a.md\" b\n",
+			err:  `line 1: unexpected "b" after closing quote`,
+		},
+		{
+			name: "empty path",
+			data: "0: \"\"\n",
+			err:  "line 1: empty path",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseLegacyHyperpaths(test.data)
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Fatalf("got error %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestReadLegacyHyperpaths(t *testing.T) {
+	data, want := longLegacyFile(100)
+	path := filepath.Join(t.TempDir(), "hyperpaths")
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readLegacyHyperpaths(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %d hyperpaths, want %d", len(got), len(want))
+	}
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// An advisory lock on a hyperpath file, held so that the CLI and the TUI
+// can work on the same file at the same time.
+type FileLock struct {
+	file *os.File
+}
+
+const LOCKS_DIR = "locks"
+
+// The lock is taken on a file in the user's cache directory rather than
+// on path itself, because WriteFileAtomic replaces path with a new file.
+// Lock files are named after the file path links to, so that a link and
+// its target share one lock.
+func lockPath(path string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(resolveSymlinks(path))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, CONFIG_DIR, LOCKS_DIR, hashString([]byte(abs))+".lock"), nil
+}
+
+// Block until the lock on path is held. Only writes take the lock: they
+// replace the file atomically, so a read always sees a whole file. Writes
+// that are journaled lock the journal first, see lockJournal.
+func LockFile(path string) (*FileLock, error) {
+	// A file cannot be written in a directory that does not exist, so
+	// report it as the file rather than as its lock.
+	if _, err := os.Stat(filepath.Dir(path)); err != nil {
+		_, err = os.Stat(path)
+		return nil, err
+	}
+	lock, err := lockPath(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(lock), 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(lock, os.O_CREATE|os.O_RDWR, NEW_FILE_PERM)
+	if err != nil {
+		return nil, err
+	}
+	if err := flock(file); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &FileLock{file: file}, nil
+}
+
+func (l *FileLock) Unlock() error {
+	if err := funlock(l.file); err != nil {
+		l.file.Close()
+		return err
+	}
+	return l.file.Close()
+}
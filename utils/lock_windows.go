@@ -0,0 +1,17 @@
+//go:build windows
+// +build windows
+
+package utils
+
+import (
+	"os"
+)
+
+// Advisory locks are not supported on windows; writes are still atomic.
+func flock(file *os.File) error {
+	return nil
+}
+
+func funlock(file *os.File) error {
+	return nil
+}
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+const BROWSER_ENV = "BROWSER"
+
+// Whether opening a bytemark marks it in-progress, see Defaults.
+var MarkOpened = false
+
+// Opens a URL, eg: in the browser. Replaced in tests so that no browser
+// is started.
+var Launch = launch
+
+// The first command of $BROWSER, eg: firefox for BROWSER=firefox:lynx.
+func browserEnv() string {
+	return strings.TrimSpace(strings.Split(os.Getenv(BROWSER_ENV), ":")[0])
+}
+
+// Whether the browser runs in the terminal until it exits. Commands in
+// $BROWSER do, as they can be terminal browsers, eg: lynx or w3m; the
+// opener of the OS returns at once.
+func BrowserInTerminal() bool {
+	return browserEnv() != ""
+}
+
+// The command that opens url: the first command of $BROWSER if it is
+// set, otherwise the opener of the OS. A %s in $BROWSER is replaced with
+// the URL, otherwise it is the last argument.
+func BrowserCommand(url string) *exec.Cmd {
+	if browser := browserEnv(); browser != "" {
+		args := strings.Fields(browser)
+		if strings.Contains(browser, "%s") {
+			for i := range args {
+				args[i] = strings.ReplaceAll(args[i], "%s", url)
+			}
+		} else {
+			args = append(args, url)
+		}
+		return exec.Command(args[0], args[1:]...)
+	}
+
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", url)
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	}
+	return exec.Command("xdg-open", url)
+}
+
+// Run the browser, in the terminal if it is run in it, see
+// BrowserInTerminal. Otherwise it is not waited for.
+func launch(url string) error {
+	cmd := BrowserCommand(url)
+	if BrowserInTerminal() {
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		return cmd.Run()
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
+
+var ErrNoComments = errors.New("No comments link.")
+
+// Open the URL of b, or its comments link if comments is true, and mark
+// it in-progress if it is unread and MarkOpened is set. Returns whether
+// b was marked.
+func (b *Bytemark) Open(comments bool) (bool, error) {
+	url := b.RootURL
+	if comments {
+		url = b.CommentsURL
+		if url == "" {
+			return false, ErrNoComments
+		}
+	}
+	if err := Launch(url); err != nil {
+		return false, err
+	}
+	if MarkOpened && (b.ReadState == "" || b.ReadState == Unread) {
+		b.SetReadState(InProgress)
+		return true, nil
+	}
+	return false, nil
+}
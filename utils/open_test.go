@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// Set $BROWSER to browser until t is done.
+func setBrowser(t *testing.T, browser string) {
+	old, set := os.LookupEnv(BROWSER_ENV)
+	os.Setenv(BROWSER_ENV, browser)
+	t.Cleanup(func() {
+		if set {
+			os.Setenv(BROWSER_ENV, old)
+		} else {
+			os.Unsetenv(BROWSER_ENV)
+		}
+	})
+}
+
+func TestBrowserCommand(t *testing.T) {
+	const url = "https://example.com/a?b=c"
+	tests := []struct {
+		browser string
+		want    []string
+	}{
+		{"firefox", []string{"firefox", url}},
+		{"firefox --new-tab", []string{"firefox", "--new-tab", url}},
+		{"firefox:lynx", []string{"firefox", url}},
+		{"lynx -dump %s", []string{"lynx", "-dump", url}},
+		{"w3m %s:lynx %s", []string{"w3m", url}},
+		{"open --url=%s", []string{"open", "--url=" + url}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.browser, func(t *testing.T) {
+			setBrowser(t, test.browser)
+			if got := BrowserCommand(url).Args; !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestOpen(t *testing.T) {
+	var opened []string
+	launch := Launch
+	Launch = func(url string) error {
+		opened = append(opened, url)
+		return nil
+	}
+	defer func() { Launch = launch }()
+
+	b := Bytemark{
+		RootURL:     "https://example.com/",
+		CommentsURL: "https://news.ycombinator.com/item?id=1",
+	}
+	if _, err := b.Open(false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Open(true); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{b.RootURL, b.CommentsURL}
+	if !reflect.DeepEqual(opened, want) {
+		t.Errorf("opened %q, want %q", opened, want)
+	}
+
+	opened = nil
+	b.CommentsURL = ""
+	if _, err := b.Open(true); !errors.Is(err, ErrNoComments) {
+		t.Errorf("got error %v, want %v", err, ErrNoComments)
+	}
+	if len(opened) != 0 {
+		t.Errorf("opened %q without a comments link", opened)
+	}
+}
+
+func TestOpenMarks(t *testing.T) {
+	launch := Launch
+	Launch = func(string) error { return nil }
+	defer func() { Launch = launch }()
+	defer func() { MarkOpened = false }()
+
+	tests := []struct {
+		markOpened bool
+		state      ReadState
+		want       ReadState
+		marked     bool
+	}{
+		{false, Unread, Unread, false},
+		{true, "", InProgress, true},
+		{true, Unread, InProgress, true},
+		{true, InProgress, InProgress, false},
+		{true, Read, Read, false},
+	}
+
+	for _, test := range tests {
+		MarkOpened = test.markOpened
+		b := Bytemark{RootURL: "https://example.com/", ReadState: test.state}
+		marked, err := b.Open(false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if marked != test.marked || b.ReadState != test.want {
+			t.Errorf("MarkOpened %v, %q: got %q (marked %v), want %q (marked %v)",
+				test.markOpened, test.state, b.ReadState, marked, test.want, test.marked)
+		}
+	}
+}
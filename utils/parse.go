@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Reasons a table could not be read as a bytemark.
+const (
+	MissingSeparator = "missing separator row"
+	UnescapedPipe    = "unescaped pipe"
+	MissingURL       = "missing URL"
+	BadDate          = "bad date"
+	MissingPipes     = "row is not enclosed in pipes"
+)
+
+// The layout written by Bytemark.SetDateTime, eg: 8/3/2021 14:5
+const dateTimeLayout = "1/2/2006 15:4"
+
+var (
+	separatorRowRegex   = regexp.MustCompile(`^\|\s*:?-+:?\s*\|$`)
+	multiColumnSepRegex = regexp.MustCompile(`^\|(\s*:?-+:?\s*\|){2,}\s*$`)
+)
+
+// A problem found in a hyperpath file. Line and Column start at 1.
+type Diagnostic struct {
+	Path   string
+	Line   int
+	Column int
+	Reason string
+}
+
+func (d Diagnostic) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", d.Path, d.Line, d.Column, d.Reason)
+}
+
+// Whether a block should be read as a bytemark. Markdown tables with
+// more than one column are left alone.
+func isBytemarkTable(text string) bool {
+	lines := strings.SplitN(text, "\n", 3)
+	if !strings.HasPrefix(lines[0], "|") {
+		return false
+	}
+	return len(lines) < 2 || !multiColumnSepRegex.MatchString(lines[1])
+}
+
+// Column of the first | in row that is not escaped, or -1.
+func unescapedPipe(row string) int {
+	for i := 0; i < len(row); i++ {
+		if row[i] == '|' && (i == 0 || row[i-1] != '\\') {
+			return i
+		}
+	}
+	return -1
+}
+
+// Parse a bytemark table that starts on line startLine of path.
+// Every problem found is reported, not only the first.
+func parseTable(path, table string, startLine int) (Bytemark, []Diagnostic) {
+	diagnostics := make([]Diagnostic, 0)
+	report := func(line, column int, reason string) {
+		diagnostics = append(diagnostics, Diagnostic{
+			Path:   path,
+			Line:   startLine + line,
+			Column: column,
+			Reason: reason,
+		})
+	}
+
+	lines := strings.Split(strings.TrimRight(table, "\n"), "\n")
+	if len(lines) < 2 || !separatorRowRegex.MatchString(lines[1]) {
+		report(1, 1, MissingSeparator)
+	} else {
+		lines[1] = ""
+	}
+
+	fields := make([]string, 0)
+	fieldLines := make([]int, 0)
+	for i, line := range lines {
+		if i == 1 && line == "" {
+			continue // The separator row.
+		}
+		line = strings.TrimRight(line, "\r")
+		if len(line) < 2 || line[0] != '|' || line[len(line)-1] != '|' {
+			report(i, 1, MissingPipes)
+			line = "|" + strings.Trim(line, "|") + "|"
+		}
+		content := line[1 : len(line)-1]
+		if col := unescapedPipe(content); col != -1 {
+			report(i, col+2, UnescapedPipe)
+		}
+		// Bad rows still take up a field so the rows after them are
+		// checked as what they are.
+		fields = append(fields, strings.TrimSpace(content))
+		fieldLines = append(fieldLines, i)
+	}
+
+	if len(fields) < 3 {
+		report(len(lines)-1, 1, MissingURL)
+	} else if fields[2] == "" {
+		report(fieldLines[2], 1, MissingURL)
+	}
+	if len(fields) >= 2 {
+		if _, err := time.Parse(dateTimeLayout, fields[1]); err != nil {
+			report(fieldLines[1], 3, BadDate)
+		}
+	}
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		if diagnostics[i].Line != diagnostics[j].Line {
+			return diagnostics[i].Line < diagnostics[j].Line
+		}
+		return diagnostics[i].Column < diagnostics[j].Column
+	})
+	if len(diagnostics) != 0 {
+		return Bytemark{}, diagnostics
+	}
+
+	bytemark := Bytemark{
+		Title:    unescapePipes(fields[0]),
+		DateTime: fields[1],
+		RootURL:  unescapePipes(fields[2]),
+	}
+	for i := 3; i < len(fields); i++ {
+		bytemark.parseRow(fields[i])
+	}
+	return bytemark, diagnostics
+}
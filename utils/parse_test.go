@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTableDiagnostics(t *testing.T) {
+	tests := []struct {
+		name      string
+		table     string
+		startLine int
+		want      []Diagnostic
+	}{
+		{
+			name:      "valid",
+			table:     "| T |\n| :-- |\n| 1/1/2021 3:00 |\n| https://x.example/ |\n",
+			startLine: 1,
+		},
+		{
+			name:      "missing separator",
+			table:     "| T |\n| 1/1/2021 3:00 |\n| https://x.example/ |",
+			startLine: 1,
+			want:      []Diagnostic{{"list.md", 2, 1, MissingSeparator}},
+		},
+		{
+			name:      "unescaped pipe",
+			table:     "| A | B |\n| :-- |\n| 1/1/2021 3:00 |\n| https://x.example/ |",
+			startLine: 1,
+			want:      []Diagnostic{{"list.md", 1, 5, UnescapedPipe}},
+		},
+		{
+			name:      "missing pipes",
+			table:     "| T |\n| :-- |\n1/1/2021 3:00\n| https://x.example/ |",
+			startLine: 1,
+			want:      []Diagnostic{{"list.md", 3, 1, MissingPipes}},
+		},
+		{
+			name:      "no URL row",
+			table:     "| T |\n| :-- |\n| 1/1/2021 3:00 |",
+			startLine: 1,
+			want:      []Diagnostic{{"list.md", 3, 1, MissingURL}},
+		},
+		{
+			name:      "empty URL",
+			table:     "| T |\n| :-- |\n| 1/1/2021 3:00 |\n|  |",
+			startLine: 1,
+			want:      []Diagnostic{{"list.md", 4, 1, MissingURL}},
+		},
+		{
+			name:      "bad date",
+			table:     "| T |\n| :-- |\n| yesterday |\n| https://x.example/ |",
+			startLine: 1,
+			want:      []Diagnostic{{"list.md", 3, 3, BadDate}},
+		},
+		{
+			name:      "every problem in order",
+			table:     "| T |\n| :-- |\n| yesterday |\n| https://x.example/|y |",
+			startLine: 1,
+			want: []Diagnostic{
+				{"list.md", 3, 3, BadDate},
+				{"list.md", 4, 21, UnescapedPipe},
+			},
+		},
+		{
+			name:      "lines of the file",
+			table:     "| T |\n| :-- |\n| yesterday |\n| https://x.example/ |",
+			startLine: 10,
+			want:      []Diagnostic{{"list.md", 12, 3, BadDate}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, got := parseTable("list.md", test.table, test.startLine)
+			if len(got) == 0 && len(test.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseTable(t *testing.T) {
+	table := "| A \\| B |\n| :-- |\n| 8/3/2021 14:5 |\n| https://x.example/?q=a\\|b |\n" +
+		"| tags: go, tui |\n| Comments: https://c.example/ |\n| by hand |\n"
+	b, diagnostics := parseTable("list.md", table, 1)
+	if len(diagnostics) != 0 {
+		t.Fatal(diagnostics)
+	}
+	want := Bytemark{
+		Title:       "A | B",
+		DateTime:    "8/3/2021 14:5",
+		RootURL:     "https://x.example/?q=a|b",
+		CommentsURL: "https://c.example/",
+		Tags:        []string{"go", "tui"},
+		Rows:        []string{"by hand"},
+		layout:      []string{"tags", "Comments", ""},
+	}
+	if !reflect.DeepEqual(b, want) {
+		t.Errorf("got %+v, want %+v", b, want)
+	}
+}
+
+func TestDiagnosticError(t *testing.T) {
+	d := Diagnostic{"list.md", 3, 5, BadDate}
+	if got, want := d.Error(), "list.md:3:5: bad date"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,84 @@
+package utils
+
+import "strings"
+
+// The positions, in runes, of the characters of pattern in text, ignoring
+// case. A run of the pattern in text is preferred; otherwise its
+// characters are found in order with anything between them.
+func FuzzyFind(pattern, text string) ([]int, bool) {
+	p := []rune(strings.ToLower(pattern))
+	t := []rune(strings.ToLower(text))
+	if len(p) == 0 {
+		return nil, true
+	}
+
+	if i := strings.Index(string(t), string(p)); i != -1 {
+		start := len([]rune(string(t)[:i]))
+		positions := make([]int, len(p))
+		for j := range p {
+			positions[j] = start + j
+		}
+		return positions, true
+	}
+
+	positions := make([]int, 0, len(p))
+	for i, r := range t {
+		if len(positions) < len(p) && r == p[len(positions)] {
+			positions = append(positions, i)
+		}
+	}
+	return positions, len(positions) == len(p)
+}
+
+// Without the scheme and www., which would match most searches.
+func searchableURL(url string) string {
+	if i := strings.Index(url, "://"); i != -1 {
+		url = url[i+len("://"):]
+	}
+	return strings.TrimPrefix(url, "www.")
+}
+
+// Where the terms of a search were found in a bytemark: in the title, and
+// in at most one other field.
+type SearchMatch struct {
+	Title     []int
+	Field     string // eg: url
+	Text      string
+	Positions []int
+}
+
+// Whether every term of query, separated by spaces, fuzzily matches the
+// title, URL, tags or notes of b.
+func (b Bytemark) Search(query string) (SearchMatch, bool) {
+	fields := []struct{ name, text string }{
+		{"url", searchableURL(b.RootURL)},
+		{TagsLabel, strings.Join(b.Tags, ", ")},
+		{NotesLabel, b.Notes},
+	}
+
+	var match SearchMatch
+	for _, term := range strings.Fields(query) {
+		if positions, ok := FuzzyFind(term, b.Title); ok {
+			match.Title = append(match.Title, positions...)
+			continue
+		}
+		found := false
+		for _, field := range fields {
+			positions, ok := FuzzyFind(term, field.text)
+			if !ok {
+				continue
+			}
+			if match.Field == "" || match.Field == field.name {
+				match.Field = field.name
+				match.Text = field.text
+				match.Positions = append(match.Positions, positions...)
+			}
+			found = true
+			break
+		}
+		if !found {
+			return SearchMatch{}, false
+		}
+	}
+	return match, true
+}
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Find the bytemarks picked by selectors. A selector is a position as
+// shown by the list command (1-based), a range of positions, eg: 2-5, or
+// the URL of a bytemark. Returns indices into bytemarks, without
+// duplicates, in the order they were selected.
+func SelectBytemarks(bytemarks []Bytemark, selectors []string) ([]int, error) {
+	indices := make([]int, 0)
+	add := func(i int) {
+		if !contains(indices, i) {
+			indices = append(indices, i)
+		}
+	}
+
+	for _, selector := range selectors {
+		if n, err := strconv.Atoi(selector); err == nil {
+			if n < 1 || n > len(bytemarks) {
+				return nil, fmt.Errorf("Invalid selection: %s", selector)
+			}
+			add(n - 1)
+			continue
+		}
+		if from, to, ok := parseRange(selector); ok {
+			if to < from || from < 1 || to > len(bytemarks) {
+				return nil, fmt.Errorf("Invalid range: %s", selector)
+			}
+			for n := from; n <= to; n++ {
+				add(n - 1)
+			}
+			continue
+		}
+
+		found := false
+		for i, bytemark := range bytemarks {
+			if bytemark.RootURL == selector {
+				add(i)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("No bytemark matches '%s'.", selector)
+		}
+	}
+	return indices, nil
+}
+
+func parseRange(s string) (from, to int, ok bool) {
+	parts := strings.SplitN(s, "-", 2)
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	from, errFrom := strconv.Atoi(parts[0])
+	to, errTo := strconv.Atoi(parts[1])
+	return from, to, errFrom == nil && errTo == nil
+}
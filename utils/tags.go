@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+)
+
+// Tags are compared without case, eg: Go and go are the same tag.
+func (b Bytemark) HasTag(tag string) bool {
+	for _, t := range b.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *Bytemark) AddTags(tags ...string) {
+	for _, tag := range tags {
+		if tag = strings.TrimSpace(tag); tag != "" && !b.HasTag(tag) {
+			b.Tags = append(b.Tags, tag)
+		}
+	}
+}
+
+func (b *Bytemark) RemoveTags(tags ...string) {
+	kept := make([]string, 0, len(b.Tags))
+	for _, t := range b.Tags {
+		removed := false
+		for _, tag := range tags {
+			if strings.EqualFold(t, strings.TrimSpace(tag)) {
+				removed = true
+			}
+		}
+		if !removed {
+			kept = append(kept, t)
+		}
+	}
+	b.Tags = kept
+}
+
+// Apply a list of tag edits, eg: "go, -read" adds go and removes read.
+func (b *Bytemark) EditTags(edits string) {
+	for _, tag := range splitTags(edits) {
+		if strings.HasPrefix(tag, "-") {
+			b.RemoveTags(tag[1:])
+		} else {
+			b.AddTags(tag)
+		}
+	}
+}
+
+// Which tags a bytemark must and must not have.
+type TagFilter struct {
+	Include []string
+	Exclude []string
+}
+
+// A filter from tags like go or -read; a leading - (or !) excludes the
+// tag.
+func ParseTagFilter(tags []string) TagFilter {
+	var filter TagFilter
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		switch {
+		case tag == "":
+		case strings.HasPrefix(tag, "-"), strings.HasPrefix(tag, "!"):
+			filter.Exclude = append(filter.Exclude, tag[1:])
+		default:
+			filter.Include = append(filter.Include, tag)
+		}
+	}
+	return filter
+}
+
+func (f TagFilter) Match(b Bytemark) bool {
+	for _, tag := range f.Include {
+		if !b.HasTag(tag) {
+			return false
+		}
+	}
+	for _, tag := range f.Exclude {
+		if b.HasTag(tag) {
+			return false
+		}
+	}
+	return true
+}
+
+type TagCount struct {
+	Tag   string
+	Count int
+}
+
+// How many bytemarks have each tag, most used first.
+func CountTags(bytemarks []Bytemark) []TagCount {
+	counts := make(map[string]*TagCount)
+	for _, bytemark := range bytemarks {
+		for _, tag := range bytemark.Tags {
+			key := strings.ToLower(tag)
+			if counts[key] == nil {
+				counts[key] = &TagCount{Tag: tag}
+			}
+			counts[key].Count++
+		}
+	}
+
+	tagCounts := make([]TagCount, 0, len(counts))
+	for _, count := range counts {
+		tagCounts = append(tagCounts, *count)
+	}
+	sort.Slice(tagCounts, func(i, j int) bool {
+		if tagCounts[i].Count != tagCounts[j].Count {
+			return tagCounts[i].Count > tagCounts[j].Count
+		}
+		return strings.ToLower(tagCounts[i].Tag) < strings.ToLower(tagCounts[j].Tag)
+	})
+	return tagCounts
+}
@@ -4,9 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"io/ioutil"
 	"os"
 	"os/user"
+	"path/filepath"
 	"strings"
 	"strconv"
 	"regexp"
@@ -15,7 +15,9 @@ import (
 
 const (
 	EARLY_EXIT = "42"
-	HP_FILEPATH = "./hyperpaths"
+	// Hyperpaths used to be kept here, relative to where hypermark was
+	// run. They now live in the config file.
+	LEGACY_HP_FILEPATH = "./hyperpaths"
 )
 
 func DeleteElement(original []string, index int) []string {
@@ -61,6 +63,15 @@ func ExpandTilde(path string) string {
 	return strings.ReplaceAll(path, "~", usr.HomeDir)
 }
 
+// The absolute form of path with ~ expanded, so that a hyperpath does not
+// depend on the directory hypermark is run from.
+func AbsPath(path string) string {
+	if abs, err := filepath.Abs(ExpandTilde(path)); err == nil {
+		return abs
+	}
+	return ExpandTilde(path)
+}
+
 func contains(arr []int, search int) bool {
 	for _, el := range arr {
 		if el == search {
@@ -70,6 +81,15 @@ func contains(arr []int, search int) bool {
 	return false
 }
 
+func containsString(arr []string, search string) bool {
+	for _, el := range arr {
+		if el == search {
+			return true
+		}
+	}
+	return false
+}
+
 func BytemarksToTables(bytemarks []Bytemark) string {
 	var output string
 	for _, bytemark := range bytemarks {
@@ -153,13 +173,66 @@ func CreateFile(path string) (*os.File, error) {
 	return outputPath, err
 }
 
+// Files opened to be overwritten, see GetFile. Each is replaced by the
+// first Write to it, so its old contents stay until the new ones are
+// ready.
+var pendingOverwrites = make(map[string]bool)
+
 func Write(
 	outputPath *os.File,
 	output string,
 	clipboardOut bool,
 ) (string, error) {
 	if !clipboardOut {
+		if outputPath != os.Stdout && output != "" && pendingOverwrites[outputPath.Name()] {
+			if err := overwrite(outputPath.Name(), output); err != nil {
+				return outputPath.Name(), err
+			}
+			delete(pendingOverwrites, outputPath.Name())
+			return outputPath.Name(), nil
+		}
+		if outputPath != os.Stdout {
+			journal, err := lockJournal()
+			if err != nil {
+				return outputPath.Name(), err
+			}
+			defer journal.Unlock()
+			lock, err := LockFile(outputPath.Name())
+			if err != nil {
+				return outputPath.Name(), err
+			}
+			defer lock.Unlock()
+
+			// The file may have been replaced since it was opened,
+			// eg: saved from the TUI. Write to the one on disk.
+			opened, err := outputPath.Stat()
+			if err != nil {
+				return outputPath.Name(), err
+			}
+			onDisk, err := os.Stat(outputPath.Name())
+			if err == nil && !os.SameFile(opened, onDisk) {
+				reopened, err := os.OpenFile(
+					outputPath.Name(),
+					os.O_APPEND|os.O_WRONLY,
+					0666,
+				)
+				if err != nil {
+					return outputPath.Name(), err
+				}
+				defer reopened.Close()
+				outputPath = reopened
+			}
+		}
+		if outputPath != os.Stdout && output != "" {
+			output = separator(outputPath.Name()) + output
+			if err := journalAppend(outputPath.Name(), output); err != nil {
+				return outputPath.Name(), err
+			}
+		}
 		_, err := outputPath.Write([]byte(output))
+		if err == nil && outputPath != os.Stdout {
+			err = outputPath.Sync()
+		}
 		return outputPath.Name(), err
 	} else {
 		err := clipboard.WriteAll(output)
@@ -167,59 +240,62 @@ func Write(
 	}
 }
 
-func trimRows(raw []string) ([]string, error) {
-	trimmed := make([]string, 0)
-	for _, field := range raw {
-		if field[0] == '|' && field[len(field)-1] == '|' {
-			trimmed = append(trimmed, field[2:len(field)-2])
-		} else {
-			return trimmed, errors.New("Malformed bytemark table.")
-		}
+// Replace the contents of file with output in one atomic write.
+func overwrite(file, output string) error {
+	journal, err := lockJournal()
+	if err != nil {
+		return err
+	}
+	defer journal.Unlock()
+	lock, err := LockFile(file)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+	if err := journalWrites(map[string][]byte{file: []byte(output)}); err != nil {
+		return err
 	}
-	return trimmed, nil
+	return WriteFileAtomic(file, []byte(output))
 }
 
-func tableToBytemark(table string) (Bytemark, error) {
-	fields, err := trimRows(DeleteElement(strings.Split(table, "\n"), 1))
+// What to write before appending a table to the file so that it is not
+// joined to the last block of the file.
+func separator(path string) string {
+	file, err := os.Open(path)
 	if err != nil {
-		return Bytemark{}, err
-	}
-	if len(fields) < 3 {
-		errMsg := "In tableToBytemark:\n"
-		errMsg += fmt.Sprintf("Only %d fields.\n", len(fields))
-		errMsg += ArrayToString(fields)
-		return Bytemark{}, errors.New(errMsg)
-	}
-	bytemark := Bytemark{
-		Title: fields[0],
-		DateTime: fields[1],
-		RootURL: fields[2],
-	}
-	if len(fields) > 3 {
-		for i := 3; i < len(fields); i++ {
-			bytemark.Rows = append(bytemark.Rows, fields[i])
-		}
+		return ""
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil || info.Size() == 0 {
+		return ""
 	}
-	return bytemark, nil
+
+	tail := make([]byte, 2)
+	offset := info.Size() - 2
+	if offset < 0 {
+		tail, offset = tail[:1], 0
+	}
+	if _, err := file.ReadAt(tail, offset); err != nil {
+		return ""
+	}
+	switch {
+	case strings.HasSuffix(string(tail), "\n\n") || string(tail) == "\n":
+		return ""
+	case strings.HasSuffix(string(tail), "\n"):
+		return "\n"
+	}
+	return "\n\n"
 }
 
+// Bytemarks found in the file. Blocks that are not bytemark tables are
+// skipped; use LoadDocument to keep them.
 func FileToBytemarks(file *os.File) ([]Bytemark, error) {
-	bytemarks := make([]Bytemark, 0)
-
-	data, err := ioutil.ReadFile(file.Name())
+	doc, err := LoadDocument(file.Name())
 	if err != nil {
-		return bytemarks, err
+		return make([]Bytemark, 0), err
 	}
-
-	tables := RemoveEmptyStrings(strings.Split(string(data), "\n\n"))
-	for _, table := range tables {
-		bytemark, err := tableToBytemark(table)
-		if err != nil {
-			return bytemarks, err
-		}
-		bytemarks = append(bytemarks, bytemark)
-	}
-	return bytemarks, nil
+	return doc.Bytemarks(), nil
 }
 
 func TestStub() {
@@ -235,10 +311,11 @@ func TestStub() {
 	bytemarks, _ := FileToBytemarks(file)
 	for _, b := range bytemarks {
 		fmt.Printf(
-			"Title: %s\nDateTime: %s\nRootURL: %s\nRows: %v\n",
+			"Title: %s\nDateTime: %s\nRootURL: %s\nTags: %v\nRows: %v\n",
 			b.Title,
 			b.DateTime,
 			b.RootURL,
+			b.Tags,
 			b.Rows,
 		)
 	}
@@ -297,15 +374,15 @@ func overwriteMode(
 			fmt.Printf("Exiting program.\n")
 			return outputPath, errors.New(EARLY_EXIT)
 		}
-		// Wipe the data on the file.
-		if err = os.Remove(fileName); err != nil {
-			log.Fatal(err)
-		}
+		// The file is replaced once there is something to replace it
+		// with, see Write. The old contents stay in the .bak when
+		// backups are kept.
+		pendingOverwrites[fileName] = true
 	}
 	// Create the file.
 	outputPath, err = os.OpenFile(
 		fileName,
-		os.O_CREATE|os.O_WRONLY,
+		os.O_APPEND|os.O_CREATE|os.O_WRONLY,
 		0666,
 	)
 	return outputPath, err
@@ -347,18 +424,6 @@ func getMainHyperpath() (string, error) {
 	var err error
 	var hyperpathChanged bool
 
-	// Create the hyperpathFile if it doesn't exist.
-	if !PathExists(HP_FILEPATH) {
-		_, err = os.OpenFile(
-			HP_FILEPATH,
-			os.O_CREATE,
-			0666,
-		)
-	}
-	if err != nil {
-		return "", err
-	}
-
 	allHyperpaths, err := GetAllHyperpaths()
 	if err != nil {
 		return "", err
@@ -385,7 +450,7 @@ func getMainHyperpath() (string, error) {
 			return "", err
 		}
 	}
-	for !PathExists(mainHyperpath) {
+	for !(Hyperpath{Path: mainHyperpath}).Exists() {
 		fmt.Printf("\nInvalid file path: %s\n", mainHyperpath)
 		mainHyperpath, hyperpathChanged, err  = getHyperpathFromUser()
 		if err != nil {
@@ -403,8 +468,24 @@ func getMainHyperpath() (string, error) {
 	return mainHyperpath, nil
 }
 
+// The file hyperpath[0] writes to; its default file if it is a
+// directory or glob.
+func mainHyperpathFile() (string, error) {
+	hyperpaths, err := GetHyperpaths()
+	if err != nil {
+		return "", err
+	}
+	if len(hyperpaths) == 0 {
+		return "", errors.New("No hyperpaths found.")
+	}
+	return hyperpaths[0].DefaultFile()
+}
+
+// target, if not empty, names the hyperpath to write to instead of
+// hyperpath[0].
 func ChooseOutputPath(
 	tail []string,
+	target string,
 	overwriteFile bool,
 	writeToStdout bool,
 	clipboardOut bool,
@@ -414,41 +495,42 @@ func ChooseOutputPath(
 	} else if len(tail) > 0 {
 		// A specific file was specified.
 		outputPath, err = GetFile(tail[0], overwriteFile)
+	} else if target != "" {
+		var hyperpath Hyperpath
+		var file string
+		if hyperpath, _, err = ResolveHyperpath(target); err != nil {
+			return outputPath, err
+		}
+		if file, err = hyperpath.DefaultFile(); err != nil {
+			return outputPath, err
+		}
+		outputPath, err = GetFile(file, overwriteFile)
 	} else {
 		// Use hyperpath.
-		var hyperpath string
-		if hyperpath, err = getMainHyperpath(); err != nil {
+		var file string
+		if _, err = getMainHyperpath(); err != nil {
+			return outputPath, err
+		}
+		if file, err = mainHyperpathFile(); err != nil {
 			return outputPath, err
 		}
-		outputPath, err = GetFile(hyperpath, overwriteFile)
+		outputPath, err = GetFile(file, overwriteFile)
 	}
 	return outputPath, err
 }
 
-func WriteHyperpaths(hyperpaths []string) error {
-	var err error
-	// remove and create hyperpaths file
-	if err = os.Remove(HP_FILEPATH); err != nil {
-		return err
-	}
-	hyperpathFile, err := os.OpenFile(
-		HP_FILEPATH,
-		os.O_CREATE|os.O_WRONLY,
-		0666,
-	)
+func WriteHyperpaths(hyperpaths []Hyperpath) error {
+	cfg, err := LoadConfig()
 	if err != nil {
 		return err
 	}
-
-	for i, hyperpath := range hyperpaths {
-		line := fmt.Sprintf("%d: %s\n", i, hyperpath)
-		hyperpathFile.WriteString(line)
-	}
-	return nil
+	cfg.Hyperpaths = hyperpaths
+	nameHyperpaths(cfg.Hyperpaths)
+	return cfg.Save()
 }
 
 func changeNthHyperpath(path string, n int) error {
-	hyperpaths, err := GetAllHyperpaths()
+	hyperpaths, err := GetHyperpaths()
 	if err != nil {
 		return err
 	}
@@ -457,69 +539,39 @@ func changeNthHyperpath(path string, n int) error {
 		return errors.New(errorString)
 	}
 
-	// Append new path or replace nth path.
+	// Append new path or replace nth path. The name stays the same.
+	path = AbsPath(path)
 	if n == len(hyperpaths) {
-		hyperpaths = append(hyperpaths, path)
+		hyperpaths = append(hyperpaths, Hyperpath{
+			Name: NewHyperpathName(hyperpaths, path),
+			Path: path,
+		})
 	} else {
-		hyperpaths[n] = path
+		hyperpaths[n].Path = path
 	}
 
 	return WriteHyperpaths(hyperpaths)
 }
 
-func pruneForHyperpaths(rawString string) []string {
-	hyperpaths := make([]string, 0)
-	hyperpathRegex := regexp.MustCompile(`\d+: .+`)
-
-	lines := strings.Split(rawString, "\n")
-	for _, line := range lines {
-		match := hyperpathRegex.FindString(line)
-		if match != "" {
-			split := strings.Split(match, " ")
-			hyperpaths = append(hyperpaths, split[1])
-		}
-	}
-
-	return hyperpaths
-}
-
 func GetAllHyperpaths() ([]string, error) {
-	var hyperpathsFile *os.File
-	var err error
-
-	if !PathExists(HP_FILEPATH) {
-		return []string{}, errors.New("hyperpaths file does not exist.")
-	} else {
-		hyperpathsFile, err = os.OpenFile(
-			HP_FILEPATH,
-			os.O_RDONLY,
-			0666,
-		)
-		if err != nil {
-			return []string{}, err
-		}
-	}
-
-	data := make([]byte, 1024)
-	var bytesRead int
-	bytesRead, err = hyperpathsFile.Read(data)
-	if bytesRead == 0 {
-		return []string{""}, nil
-	}
+	cfg, err := LoadConfig()
 	if err != nil {
 		return []string{}, err
 	}
-
-	hyperpaths := pruneForHyperpaths(string(data))
-	if len(hyperpaths) == 0 {
-		return hyperpaths, errors.New("No hyperpaths found.")
+	if len(cfg.Hyperpaths) == 0 {
+		return []string{""}, nil
+	}
+	paths := make([]string, 0, len(cfg.Hyperpaths))
+	for _, hyperpath := range cfg.Hyperpaths {
+		paths = append(paths, hyperpath.Path)
 	}
-	return hyperpaths, nil
+	return paths, nil
 }
 
 func EditNthHyperpath(path string, n int) (written, valid bool) {
-	// Check if the file exists. If so edit the hyperpath.
-	if PathExists(path) && !isDirectory(path) {
+	// Check if the file, directory or glob exists. If so edit the
+	// hyperpath.
+	if (Hyperpath{Path: path}).Exists() {
 		err := changeNthHyperpath(path, n)
 		if err != nil {
 			log.Fatal(err)